@@ -23,18 +23,25 @@ import (
 
 	"github.com/Meikwei/go-tools/apiresp"
 	"github.com/Meikwei/go-tools/errs"
-	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/Meikwei/go-tools/mcontext"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
+	"github.com/openimsdk/protocol/constant"
 	"google.golang.org/grpc"
 )
 
 type Option[A, B any] struct {
 	BindAfter func(*A) error
 	RespAfter func(*B) error
+	// Codec decodes the request body and encodes the response data, see apiresp.Codec.
+	Codec apiresp.Codec
 }
 
 func Call[A, B, C any](rpc func(client C, ctx context.Context, req *A, options ...grpc.CallOption) (*B, error), client C, c *gin.Context, opts ...*Option[A, B]) {
+	for _, opt := range opts {
+		if opt.Codec != nil {
+			apiresp.SetGinCodec(c, opt.Codec)
+		}
+	}
 	req, err := ParseRequestNotCheck[A](c)
 	if err != nil {
 		apiresp.GinError(c, err)
@@ -49,7 +56,7 @@ func Call[A, B, C any](rpc func(client C, ctx context.Context, req *A, options .
 			return
 		}
 	}
-	if err := checker.Validate(req); err != nil {
+	if err := checker.Validate(checkContext(c), req); err != nil {
 		apiresp.GinError(c, err) // args option error
 		return
 	}
@@ -70,9 +77,19 @@ func Call[A, B, C any](rpc func(client C, ctx context.Context, req *A, options .
 	apiresp.GinSuccess(c, resp) // rpc call success
 }
 
+// checkContext returns the request context of c carrying the operationID set by
+// mw.GinParseOperationID, for the context checks run by checker.Validate.
+func checkContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if operationID := c.GetString(constant.OperationID); operationID != "" {
+		ctx = mcontext.SetOperationID(ctx, operationID)
+	}
+	return ctx
+}
+
 func ParseRequestNotCheck[T any](c *gin.Context) (*T, error) {
 	var req T
-	if err := c.ShouldBindWith(&req, jsonBind); err != nil {
+	if err := bindRequest(c, &req); err != nil {
 		return nil, err
 	}
 	return &req, nil
@@ -83,15 +100,15 @@ func ParseRequest[T any](c *gin.Context) (*T, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := checker.Validate(&req); err != nil {
+	if err := checker.Validate(checkContext(c), req); err != nil {
 		return nil, err
 	}
 	return req, nil
 }
 
-type jsonBinding struct{}
-
-var jsonBind binding.Binding = jsonBinding{}
+type jsonBinding struct {
+	codec apiresp.Codec
+}
 
 func (jsonBinding) Name() string {
 	return "json"
@@ -108,12 +125,10 @@ func (b jsonBinding) Bind(req *http.Request, obj any) error {
 	return errs.Wrap(b.BindBody(body, obj))
 }
 
-func (jsonBinding) BindBody(body []byte, obj any) error {
-	if err := jsonutil.JsonUnmarshal(body, obj); err != nil {
-		return err
-	}
-	if binding.Validator == nil {
-		return nil
+func (b jsonBinding) BindBody(body []byte, obj any) error {
+	codec := b.codec
+	if codec == nil {
+		codec = apiresp.DefaultCodec()
 	}
-	return errs.Wrap(binding.Validator.ValidateStruct(obj))
+	return codec.Unmarshal(body, obj)
 }
@@ -0,0 +1,209 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2r
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindTag is the struct tag used to map query, form, multipart and path values onto request
+// fields. Using the json tag keeps the field names identical to the JSON body, so proto
+// generated request types bind the same way whatever the transport.
+const bindTag = "json"
+
+// defaultMultipartMemory is the maximum number of bytes of a multipart body kept in memory,
+// the rest is stored in temporary files.
+const defaultMultipartMemory = 32 << 20
+
+var (
+	queryBind     binding.Binding = queryBinding{}
+	formBind      binding.Binding = formBinding{}
+	multipartBind binding.Binding = multipartBinding{}
+)
+
+// requestBinding selects the binding for c: query for GET and HEAD, then by Content-Type,
+// using the apiresp encoders for non JSON bodies such as protobuf and MessagePack, and
+// falling back to JSON.
+func requestBinding(c *gin.Context) binding.Binding {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return queryBind
+	}
+	switch c.ContentType() {
+	case binding.MIMEPOSTForm:
+		return formBind
+	case binding.MIMEMultipartPOSTForm:
+		return multipartBind
+	case binding.MIMEJSON, "":
+		return jsonBinding{codec: apiresp.GinCodec(c)}
+	}
+	if enc, ok := apiresp.EncoderFor(c.ContentType()); ok && enc != apiresp.JSONEncoder {
+		return encoderBinding{enc: enc}
+	}
+	return jsonBinding{codec: apiresp.GinCodec(c)}
+}
+
+// bindRequest binds obj with the binding selected for c, applies the path params and then
+// validates the result once, so fields filled from the path satisfy their binding rules. The
+// bindings only decode, validation is left to bindRequest.
+func bindRequest(c *gin.Context, obj any) error {
+	if err := requestBinding(c).Bind(c.Request, obj); err != nil {
+		return err
+	}
+	if err := bindParams(c, obj); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+// bindParams applies the gin path params on top of the already bound request.
+func bindParams(c *gin.Context, obj any) error {
+	if len(c.Params) == 0 {
+		return nil
+	}
+	params := make(map[string][]string, len(c.Params))
+	for _, param := range c.Params {
+		params[param.Key] = append(params[param.Key], param.Value)
+	}
+	if err := binding.MapFormWithTag(obj, params, bindTag); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+func (queryBinding) Bind(req *http.Request, obj any) error {
+	if err := binding.MapFormWithTag(obj, req.URL.Query(), bindTag); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
+
+type formBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+func (formBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseForm(); err != nil {
+		return errs.WrapMsg(err, "parse form failed", "method", req.Method, "url", req.URL.String())
+	}
+	if err := binding.MapFormWithTag(obj, req.Form, bindTag); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
+
+type multipartBinding struct{}
+
+func (multipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+func (multipartBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseMultipartForm(defaultMultipartMemory); err != nil {
+		return errs.WrapMsg(err, "parse multipart form failed", "method", req.Method, "url", req.URL.String())
+	}
+	if err := binding.MapFormWithTag(obj, req.MultipartForm.Value, bindTag); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return bindFiles(obj, req.MultipartForm.File)
+}
+
+// encoderBinding decodes the request body with an apiresp.Encoder.
+type encoderBinding struct {
+	enc apiresp.Encoder
+}
+
+func (b encoderBinding) Name() string {
+	return b.enc.ContentType()
+}
+
+func (b encoderBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errs.New("invalid request").Wrap()
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errs.WrapMsg(err, "read request body failed", "method", req.Method, "url", req.URL.String())
+	}
+	return b.enc.Decode(body, obj)
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// bindFiles sets the *multipart.FileHeader and []*multipart.FileHeader fields of obj from the
+// file parts with the matching name.
+func bindFiles(obj any, files map[string][]*multipart.FileHeader) error {
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return errs.New("multipart binding requires a pointer to struct", "type", val.Type().String()).Wrap()
+	}
+	val = val.Elem()
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "" || len(files[name]) == 0 {
+			continue
+		}
+		switch field.Type {
+		case fileHeaderType:
+			val.Field(i).Set(reflect.ValueOf(files[name][0]))
+		case fileHeaderSliceType:
+			val.Field(i).Set(reflect.ValueOf(files[name]))
+		}
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	tag := field.Tag.Get(bindTag)
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func validateStruct(obj any) error {
+	if binding.Validator == nil {
+		return nil
+	}
+	return errs.Wrap(binding.Validator.ValidateStruct(obj))
+}
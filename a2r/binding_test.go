@@ -0,0 +1,145 @@
+package a2r
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/mcontext"
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type bindReq struct {
+	UserID  string                  `json:"userID,omitempty"`
+	GroupID string                  `json:"groupID"`
+	Limit   int32                   `json:"limit"`
+	Tags    []string                `json:"tags"`
+	Avatar  *multipart.FileHeader   `json:"avatar"`
+	Photos  []*multipart.FileHeader `json:"photos"`
+}
+
+func parse(t *testing.T, route, method, target, contentType string, body []byte) *bindReq {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var (
+		req *bindReq
+		err error
+	)
+	engine.Handle(method, route, func(c *gin.Context) {
+		req, err = ParseRequestNotCheck[bindReq](c)
+	})
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	assert.NoError(t, err)
+	return req
+}
+
+func TestParseRequestQuery(t *testing.T) {
+	req := parse(t, "/users", http.MethodGet, "/users?userID=u1&limit=20&tags=a&tags=b", "", nil)
+	assert.Equal(t, &bindReq{UserID: "u1", Limit: 20, Tags: []string{"a", "b"}}, req)
+}
+
+func TestParseRequestPathParams(t *testing.T) {
+	req := parse(t, "/groups/:groupID", http.MethodPost, "/groups/g1", "application/json", []byte(`{"userID":"u1","groupID":"ignored"}`))
+	assert.Equal(t, &bindReq{UserID: "u1", GroupID: "g1"}, req)
+}
+
+func TestParseRequestForm(t *testing.T) {
+	form := url.Values{"userID": {"u1"}, "limit": {"5"}}
+	req := parse(t, "/users", http.MethodPost, "/users", "application/x-www-form-urlencoded", []byte(form.Encode()))
+	assert.Equal(t, &bindReq{UserID: "u1", Limit: 5}, req)
+}
+
+func TestParseRequestMultipart(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	assert.NoError(t, w.WriteField("userID", "u1"))
+	for _, name := range []string{"avatar", "photos", "photos"} {
+		part, err := w.CreateFormFile(name, name+".png")
+		assert.NoError(t, err)
+		_, _ = part.Write([]byte(strings.Repeat("x", 8)))
+	}
+	assert.NoError(t, w.Close())
+	req := parse(t, "/upload", http.MethodPost, "/upload", w.FormDataContentType(), body.Bytes())
+	assert.Equal(t, "u1", req.UserID)
+	if assert.NotNil(t, req.Avatar) {
+		assert.Equal(t, "avatar.png", req.Avatar.Filename)
+		assert.EqualValues(t, 8, req.Avatar.Size)
+	}
+	assert.Len(t, req.Photos, 2)
+}
+
+func TestParseRequestProtobuf(t *testing.T) {
+	body, err := proto.Marshal(&grpc_health_v1.HealthCheckRequest{Service: "msg"})
+	assert.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/health/check", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", apiresp.MIMEProtobuf)
+	req, err := ParseRequestNotCheck[grpc_health_v1.HealthCheckRequest](c)
+	assert.NoError(t, err)
+	assert.Equal(t, "msg", req.Service)
+}
+
+func TestParseRequestRequiredPathParam(t *testing.T) {
+	type groupReq struct {
+		GroupID string `json:"groupID" binding:"required"`
+		UserID  string `json:"userID"`
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var (
+		req *groupReq
+		err error
+	)
+	engine.POST("/groups/:groupID", func(c *gin.Context) {
+		req, err = ParseRequestNotCheck[groupReq](c)
+	})
+	r := httptest.NewRequest(http.MethodPost, "/groups/g1", strings.NewReader(`{"userID":"u1"}`))
+	r.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	assert.NoError(t, err)
+	assert.Equal(t, &groupReq{GroupID: "g1", UserID: "u1"}, req)
+}
+
+type ctxCheckReq struct {
+	UserID string `json:"userID"`
+	ctx    context.Context
+}
+
+func (r *ctxCheckReq) Check(ctx context.Context) error {
+	r.ctx = ctx
+	return nil
+}
+
+func TestParseRequestCheckContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var req *ctxCheckReq
+	engine.GET("/users", func(c *gin.Context) {
+		c.Set(constant.OperationID, "op1")
+		var err error
+		req, err = ParseRequest[ctxCheckReq](c)
+		assert.NoError(t, err)
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users?userID=u1", nil))
+	if assert.NotNil(t, req) && assert.NotNil(t, req.ctx) {
+		_, isGin := req.ctx.(*gin.Context)
+		assert.False(t, isGin)
+		assert.Equal(t, "op1", mcontext.GetOperationID(req.ctx))
+	}
+}
@@ -0,0 +1,530 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2r
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const openAPIVersion = "3.0.3"
+
+// OpenAPIInfo configures the generated OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+	// ErrorCodes are documented in addition to the codes predefined in errs.
+	ErrorCodes []errs.CodeError
+}
+
+var predefinedErrorCodes = []errs.CodeError{
+	errs.ErrInternalServer,
+	errs.ErrArgs,
+	errs.ErrNoPermission,
+	errs.ErrDuplicateKey,
+	errs.ErrRecordNotFound,
+	errs.ErrTokenExpired,
+	errs.ErrTokenMalformed,
+	errs.ErrTokenNotValidYet,
+	errs.ErrTokenUnknown,
+}
+
+// ServeOpenAPI serves the OpenAPI document of the routes recorded in reg as JSON at relativePath.
+func ServeOpenAPI(router gin.IRouter, relativePath string, reg *Registry, info OpenAPIInfo) {
+	router.GET(relativePath, OpenAPIHandler(reg, info))
+}
+
+// OpenAPIHandler returns a gin handler writing the OpenAPI document of the routes recorded in reg.
+// The document is built on every request, so routes registered later are included.
+func OpenAPIHandler(reg *Registry, info OpenAPIInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := OpenAPIJSON(reg, info)
+		if err != nil {
+			apiresp.GinError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}
+}
+
+// OpenAPIJSON builds the OpenAPI 3 document of the routes recorded in reg. Request and response
+// schemas are derived from the route types and every response is wrapped in the
+// apiresp.ApiResponse envelope.
+func OpenAPIJSON(reg *Registry, info OpenAPIInfo) ([]byte, error) {
+	return jsonutil.JsonMarshal(buildOpenAPI(info, reg.Routes()))
+}
+
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIDocInfo                          `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIDocInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	OperationID string                      `json:"operationId"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+func buildOpenAPI(info OpenAPIInfo, list []Route) *openAPIDoc {
+	doc := &openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info: openAPIDocInfo{
+			Title:       info.Title,
+			Version:     info.Version,
+			Description: info.Description,
+		},
+		Paths:      make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{Schemas: make(map[string]*openAPISchema)},
+	}
+	doc.Components.Schemas["ErrCode"] = errCodeSchema(append(append([]errs.CodeError{}, predefinedErrorCodes...), info.ErrorCodes...))
+	gen := &schemaGenerator{schemas: doc.Components.Schemas}
+	for _, route := range list {
+		codec := route.Codec
+		if codec == nil {
+			codec = apiresp.DefaultCodec()
+		}
+		gen.protoJSON, _ = codec.(*apiresp.ProtoJSONCodec)
+		p, params := openAPIPath(route.Path)
+		op := &openAPIOperation{
+			Summary:     route.Summary,
+			OperationID: operationID(route),
+			Parameters:  params,
+			Responses: map[string]*openAPIResponse{
+				"200": {
+					Description: "apiresp.ApiResponse, errCode is 0 on success",
+					Content: map[string]openAPIMediaType{
+						"application/json": {Schema: envelopeSchema(gen.schema(route.Response))},
+					},
+				},
+			},
+		}
+		if route.Request != nil {
+			if route.Method == http.MethodGet || route.Method == http.MethodHead {
+				op.Parameters = append(op.Parameters, gen.queryParameters(route.Request, params)...)
+			} else {
+				op.RequestBody = &openAPIRequestBody{
+					Required: true,
+					Content: map[string]openAPIMediaType{
+						gen.requestContentType(route.Request): {Schema: gen.schema(route.Request)},
+					},
+				}
+			}
+		}
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[p][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+func errCodeSchema(codes []errs.CodeError) *openAPISchema {
+	seen := map[int]string{0: "success"}
+	for _, code := range codes {
+		if _, ok := seen[code.Code()]; !ok {
+			seen[code.Code()] = code.Msg()
+		}
+	}
+	keys := make([]int, 0, len(seen))
+	for code := range seen {
+		keys = append(keys, code)
+	}
+	sort.Ints(keys)
+	enum := make([]any, 0, len(keys))
+	desc := make([]string, 0, len(keys))
+	for _, code := range keys {
+		enum = append(enum, code)
+		desc = append(desc, fmt.Sprintf("%d: %s", code, seen[code]))
+	}
+	return &openAPISchema{Type: "integer", Enum: enum, Description: strings.Join(desc, "\n")}
+}
+
+func envelopeSchema(data *openAPISchema) *openAPISchema {
+	return &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"errCode": {Ref: "#/components/schemas/ErrCode"},
+			"errMsg":  {Type: "string"},
+			"errDlt":  {Type: "string"},
+			"data":    data,
+		},
+	}
+}
+
+// openAPIPath converts the gin path params of p to the OpenAPI template syntax.
+func openAPIPath(p string) (string, []openAPIParameter) {
+	var params []openAPIParameter
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		params = append(params, openAPIParameter{
+			Name:     segment[1:],
+			In:       "path",
+			Required: true,
+			Schema:   &openAPISchema{Type: "string"},
+		})
+		segments[i] = "{" + segment[1:] + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(route Route) string {
+	if route.Summary != "" {
+		return route.Summary
+	}
+	return strings.ToLower(route.Method) + strings.ReplaceAll(route.Path, "/", "_")
+}
+
+type schemaGenerator struct {
+	schemas   map[string]*openAPISchema
+	protoJSON *apiresp.ProtoJSONCodec
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderElem = fileHeaderType.Elem()
+)
+
+func (g *schemaGenerator) requestContentType(t reflect.Type) string {
+	if g.hasFiles(t) {
+		return binding.MIMEMultipartPOSTForm
+	}
+	return "application/json"
+}
+
+func (g *schemaGenerator) hasFiles(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if ft := t.Field(i).Type; ft == fileHeaderType || ft == fileHeaderSliceType {
+			return true
+		}
+	}
+	return false
+}
+
+// queryParameters documents the top level fields of t as query parameters, skipping path params.
+// Query values are bound by bindTag whatever the codec, so the parameters are named after the Go
+// json tags, such as group_id for proto generated types, and not after the protojson names.
+func (g *schemaGenerator) queryParameters(t reflect.Type, pathParams []openAPIParameter) []openAPIParameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	schema := g.structObject(t)
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+	skip := make(map[string]bool, len(pathParams))
+	for _, p := range pathParams {
+		skip[p.Name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	params := make([]openAPIParameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, openAPIParameter{Name: name, In: "query", Schema: schema.Properties[name]})
+	}
+	return params
+}
+
+func (g *schemaGenerator) isProtoJSON(t reflect.Type) bool {
+	return g.protoJSON != nil && t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(messageType)
+}
+
+// schema returns the schema of t, registering named structs and messages as components.
+func (g *schemaGenerator) schema(t reflect.Type) *openAPISchema {
+	if t == nil {
+		return &openAPISchema{}
+	}
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	if g.isProtoJSON(t) {
+		md := reflect.New(t).Interface().(proto.Message).ProtoReflect().Descriptor()
+		return g.protoMessage(md)
+	}
+	switch t {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time", Nullable: nullable}
+	case fileHeaderElem:
+		return &openAPISchema{Type: "string", Format: "binary"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean", Nullable: nullable}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &openAPISchema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float", Nullable: nullable}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double", Nullable: nullable}
+	case reflect.String:
+		return &openAPISchema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &openAPISchema{Type: "array", Items: g.schema(t.Elem()), Nullable: true}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structObject(t)
+		}
+		name := componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &openAPISchema{Type: "object"} // placeholder for recursive types
+			g.schemas[name] = g.structObject(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	default:
+		return &openAPISchema{}
+	}
+}
+
+// structObject follows the encoding/json rules: json tags, "-" and embedded structs.
+func (g *schemaGenerator) structObject(t reflect.Type) *openAPISchema {
+	obj := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := field.Type
+		if field.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range g.structObject(ft).Properties {
+					if _, ok := obj.Properties[k]; !ok {
+						obj.Properties[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "string") {
+			obj.Properties[name] = &openAPISchema{Type: "string"}
+			continue
+		}
+		obj.Properties[name] = g.schema(field.Type)
+	}
+	return obj
+}
+
+// componentName names Go types after their package, so they never collide with the proto full
+// names used for messages encoded with protojson.
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndexByte(pkg, '/'); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+// protoMessage follows the proto3 JSON mapping used by apiresp.ProtoJSONCodec.
+func (g *schemaGenerator) protoMessage(md protoreflect.MessageDescriptor) *openAPISchema {
+	if schema := wellKnownSchema(md); schema != nil {
+		return schema
+	}
+	name := string(md.FullName())
+	if _, ok := g.schemas[name]; !ok {
+		g.schemas[name] = &openAPISchema{Type: "object"} // placeholder for recursive types
+		g.schemas[name] = g.protoObject(md)
+	}
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *schemaGenerator) protoObject(md protoreflect.MessageDescriptor) *openAPISchema {
+	obj := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := fd.JSONName()
+		if g.protoJSON.MarshalOptions.UseProtoNames {
+			name = fd.TextName()
+		}
+		obj.Properties[name] = g.protoField(fd)
+	}
+	return obj
+}
+
+func (g *schemaGenerator) protoField(fd protoreflect.FieldDescriptor) *openAPISchema {
+	switch {
+	case fd.IsMap():
+		return &openAPISchema{Type: "object", AdditionalProperties: g.protoScalar(fd.MapValue())}
+	case fd.IsList():
+		return &openAPISchema{Type: "array", Items: g.protoScalar(fd)}
+	default:
+		return g.protoScalar(fd)
+	}
+}
+
+func (g *schemaGenerator) protoScalar(fd protoreflect.FieldDescriptor) *openAPISchema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &openAPISchema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &openAPISchema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &openAPISchema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &openAPISchema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		enum := make([]any, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			enum = append(enum, string(values.Get(i).Name()))
+		}
+		return &openAPISchema{Type: "string", Enum: enum}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.protoMessage(fd.Message())
+	default:
+		return &openAPISchema{}
+	}
+}
+
+// wellKnownSchema returns the schema of the google.protobuf types with a special JSON mapping.
+func wellKnownSchema(md protoreflect.MessageDescriptor) *openAPISchema {
+	if md.ParentFile() == nil || md.ParentFile().Package() != "google.protobuf" {
+		return nil
+	}
+	switch md.Name() {
+	case "Timestamp":
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case "Duration", "FieldMask":
+		return &openAPISchema{Type: "string"}
+	case "StringValue":
+		return &openAPISchema{Type: "string", Nullable: true}
+	case "BytesValue":
+		return &openAPISchema{Type: "string", Format: "byte", Nullable: true}
+	case "BoolValue":
+		return &openAPISchema{Type: "boolean", Nullable: true}
+	case "Int32Value":
+		return &openAPISchema{Type: "integer", Format: "int32", Nullable: true}
+	case "UInt32Value":
+		return &openAPISchema{Type: "integer", Format: "int64", Nullable: true}
+	case "Int64Value", "UInt64Value":
+		return &openAPISchema{Type: "string", Format: "int64", Nullable: true}
+	case "FloatValue":
+		return &openAPISchema{Type: "number", Format: "float", Nullable: true}
+	case "DoubleValue":
+		return &openAPISchema{Type: "number", Format: "double", Nullable: true}
+	case "Struct", "Any", "Empty":
+		return &openAPISchema{Type: "object"}
+	case "ListValue":
+		return &openAPISchema{Type: "array", Items: &openAPISchema{}}
+	case "Value":
+		return &openAPISchema{}
+	default:
+		return nil
+	}
+}
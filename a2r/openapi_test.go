@@ -0,0 +1,141 @@
+package a2r
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type docUser struct {
+	UserID   string    `json:"userID"`
+	Nickname string    `json:"nickname,omitempty"`
+	Friends  []docUser `json:"friends"`
+	Created  time.Time `json:"created"`
+	internal int
+}
+
+type docReq struct {
+	GroupID string `json:"groupID"`
+	Limit   int32  `json:"limit"`
+}
+
+type docResp struct {
+	Users []*docUser `json:"users"`
+	Total int64      `json:"total,string"`
+}
+
+func decodeDoc(t *testing.T, doc *openAPIDoc) map[string]any {
+	data, err := jsonutil.JsonMarshal(doc)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func lookup(m any, keys ...string) any {
+	for _, key := range keys {
+		obj, ok := m.(map[string]any)
+		if !ok {
+			return nil
+		}
+		m = obj[key]
+	}
+	return m
+}
+
+func TestBuildOpenAPIStruct(t *testing.T) {
+	doc := decodeDoc(t, buildOpenAPI(OpenAPIInfo{Title: "api", Version: "v1"}, []Route{{
+		Method:   http.MethodGet,
+		Path:     "/group/:groupID/users",
+		Request:  reflect.TypeOf(docReq{}),
+		Response: reflect.TypeOf(docResp{}),
+	}}))
+	assert.Equal(t, openAPIVersion, doc["openapi"])
+
+	op := lookup(doc, "paths", "/group/{groupID}/users", "get")
+	params := lookup(op, "parameters").([]any)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "groupID", lookup(params[0], "name"))
+		assert.Equal(t, "path", lookup(params[0], "in"))
+		assert.Equal(t, "limit", lookup(params[1], "name"))
+		assert.Equal(t, "query", lookup(params[1], "in"))
+	}
+
+	data := lookup(op, "responses", "200", "content", "application/json", "schema", "properties", "data")
+	assert.Equal(t, "#/components/schemas/a2r.docResp", lookup(data, "$ref"))
+	resp := lookup(doc, "components", "schemas", "a2r.docResp", "properties")
+	assert.Equal(t, "string", lookup(resp, "total", "type"))
+	assert.Equal(t, "#/components/schemas/a2r.docUser", lookup(resp, "users", "items", "$ref"))
+	user := lookup(doc, "components", "schemas", "a2r.docUser", "properties").(map[string]any)
+	assert.Len(t, user, 4)
+	assert.Equal(t, "date-time", lookup(user, "created", "format"))
+	assert.Equal(t, "#/components/schemas/a2r.docUser", lookup(user, "friends", "items", "$ref"))
+}
+
+func TestBuildOpenAPIProto(t *testing.T) {
+	custom := errs.NewCodeError(20001, "GroupNotExist")
+	doc := decodeDoc(t, buildOpenAPI(OpenAPIInfo{ErrorCodes: []errs.CodeError{custom}}, []Route{{
+		Method:   http.MethodPost,
+		Path:     "/health/check",
+		Summary:  "grpc.health.v1.Health.Check",
+		Request:  reflect.TypeOf(grpc_health_v1.HealthCheckRequest{}),
+		Response: reflect.TypeOf(grpc_health_v1.HealthCheckResponse{}),
+		Codec:    apiresp.NewProtoJSONCodec(false, false),
+	}}))
+	op := lookup(doc, "paths", "/health/check", "post")
+	assert.Equal(t, "grpc.health.v1.Health.Check", lookup(op, "operationId"))
+	assert.Equal(t, "#/components/schemas/grpc.health.v1.HealthCheckRequest",
+		lookup(op, "requestBody", "content", "application/json", "schema", "$ref"))
+	status := lookup(doc, "components", "schemas", "grpc.health.v1.HealthCheckResponse", "properties", "status")
+	assert.Equal(t, "string", lookup(status, "type"))
+	assert.Contains(t, lookup(status, "enum"), "SERVING")
+
+	codes := lookup(doc, "components", "schemas", "ErrCode", "enum").([]any)
+	assert.Contains(t, codes, float64(errs.ArgsError))
+	assert.Contains(t, codes, float64(20001))
+}
+
+func TestBuildOpenAPIProtoQuery(t *testing.T) {
+	doc := decodeDoc(t, buildOpenAPI(OpenAPIInfo{}, []Route{{
+		Method:   http.MethodGet,
+		Path:     "/requests/:request_id",
+		Request:  reflect.TypeOf(errdetails.RequestInfo{}),
+		Response: reflect.TypeOf(errdetails.RequestInfo{}),
+		Codec:    apiresp.NewProtoJSONCodec(false, false),
+	}}))
+	params := lookup(doc, "paths", "/requests/{request_id}", "get", "parameters").([]any)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "request_id", lookup(params[0], "name"))
+		assert.Equal(t, "path", lookup(params[0], "in"))
+		assert.Equal(t, "serving_data", lookup(params[1], "name"))
+		assert.Equal(t, "query", lookup(params[1], "in"))
+	}
+}
+
+func TestOpenAPIRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	reg := NewRegistry()
+	Handle(engine.Group("/api"), reg, http.MethodGet, "/health", grpc_health_v1.HealthClient.Check, grpc_health_v1.HealthClient(healthClient{}))
+	Handle(engine, nil, http.MethodGet, "/undocumented", grpc_health_v1.HealthClient.Check, grpc_health_v1.HealthClient(healthClient{}))
+	ServeOpenAPI(engine, "/openapi.json", reg, OpenAPIInfo{Title: "api"})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	paths := lookup(doc, "paths").(map[string]any)
+	assert.Len(t, paths, 1)
+	assert.Contains(t, paths, "/api/health")
+}
@@ -0,0 +1,286 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2r
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/checker"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ServiceOption customizes the routes mounted by RegisterService.
+type ServiceOption func(*service)
+
+// WithPrefix sets the path prefix of the service routes. It defaults to the snake_case service name.
+func WithPrefix(prefix string) ServiceOption {
+	return func(s *service) {
+		s.prefix = prefix
+	}
+}
+
+// WithRegistry records the service routes in reg, so that they appear in its OpenAPI document.
+func WithRegistry(reg *Registry) ServiceOption {
+	return func(s *service) {
+		s.registry = reg
+	}
+}
+
+// WithMiddleware adds gin middleware in front of the route of the named rpc method.
+func WithMiddleware(method string, handlers ...gin.HandlerFunc) ServiceOption {
+	return func(s *service) {
+		m := s.method(method)
+		m.middleware = append(m.middleware, handlers...)
+	}
+}
+
+// WithPath overrides the path segment of the named rpc method, which defaults to its snake_case name.
+func WithPath(method, relativePath string) ServiceOption {
+	return func(s *service) {
+		s.method(method).path = relativePath
+	}
+}
+
+// WithSkip excludes the named rpc methods from registration.
+func WithSkip(methods ...string) ServiceOption {
+	return func(s *service) {
+		for _, method := range methods {
+			s.method(method).skip = true
+		}
+	}
+}
+
+// WithCodec selects the request and response codec of the named rpc method.
+func WithCodec(method string, codec apiresp.Codec) ServiceOption {
+	return func(s *service) {
+		s.method(method).codec = codec
+	}
+}
+
+// WithMethodOption attaches the BindAfter and RespAfter hooks and the Codec of opt to the named rpc method.
+// A and B must be the request and response message types of that method, RegisterService fails otherwise.
+func WithMethodOption[A, B any](method string, opt *Option[A, B]) ServiceOption {
+	return func(s *service) {
+		m := s.method(method)
+		m.options = append(m.options, optionTypes{req: reflect.TypeOf((*A)(nil)), resp: reflect.TypeOf((*B)(nil))})
+		if opt.Codec != nil {
+			m.codec = opt.Codec
+		}
+		if opt.BindAfter != nil {
+			m.bindAfter = append(m.bindAfter, func(req any) error {
+				a, ok := req.(*A)
+				if !ok {
+					return errs.ErrInternalServer.WrapMsg("bind option type mismatch", "method", method)
+				}
+				return opt.BindAfter(a)
+			})
+		}
+		if opt.RespAfter != nil {
+			m.respAfter = append(m.respAfter, func(resp any) error {
+				b, ok := resp.(*B)
+				if !ok {
+					return errs.ErrInternalServer.WrapMsg("resp option type mismatch", "method", method)
+				}
+				return opt.RespAfter(b)
+			})
+		}
+	}
+}
+
+type service struct {
+	prefix   string
+	registry *Registry
+	methods  map[string]*methodConfig
+}
+
+type methodConfig struct {
+	path       string
+	skip       bool
+	codec      apiresp.Codec
+	middleware []gin.HandlerFunc
+	bindAfter  []func(req any) error
+	respAfter  []func(resp any) error
+	options    []optionTypes
+}
+
+// optionTypes records the message types a WithMethodOption was declared with.
+type optionTypes struct {
+	req, resp reflect.Type
+}
+
+func (s *service) method(name string) *methodConfig {
+	m, ok := s.methods[name]
+	if !ok {
+		m = &methodConfig{}
+		s.methods[name] = m
+	}
+	return m
+}
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	callOptionType = reflect.TypeOf([]grpc.CallOption(nil))
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+	messageType    = reflect.TypeOf((*proto.Message)(nil)).Elem()
+)
+
+// RegisterService mounts one POST route per unary method of sd on router, calling the method of
+// the same name on client. Streaming methods are skipped. Each route behaves like Call: the
+// request is bound, passed through the BindAfter hooks and checker.Validate, and the response is
+// passed through the RespAfter hooks before it is written with apiresp. Options naming a method
+// that sd does not have, or a WithMethodOption with other message types, make it fail before any
+// route is mounted.
+func RegisterService(router gin.IRouter, sd protoreflect.ServiceDescriptor, client any, opts ...ServiceOption) error {
+	s := &service{
+		prefix:  "/" + snakeCase(string(sd.Name())),
+		methods: make(map[string]*methodConfig),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	clientValue := reflect.ValueOf(client)
+	if !clientValue.IsValid() {
+		return errs.New("nil rpc client", "service", sd.FullName()).Wrap()
+	}
+	methods := sd.Methods()
+	for name := range s.methods {
+		if methods.ByName(protoreflect.Name(name)) == nil {
+			return errs.New("rpc service has no method", "service", sd.FullName(), "method", name).Wrap()
+		}
+	}
+	group := router.Group(s.prefix)
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			continue
+		}
+		name := string(md.Name())
+		conf := s.method(name)
+		if conf.skip {
+			continue
+		}
+		rpc := clientValue.MethodByName(name)
+		if !rpc.IsValid() {
+			return errs.New("rpc client missing method", "service", sd.FullName(), "method", name).Wrap()
+		}
+		reqType, err := checkUnaryMethod(md, rpc.Type())
+		if err != nil {
+			return err
+		}
+		respType := rpc.Type().Out(0)
+		for _, opt := range conf.options {
+			if opt.req != reqType || opt.resp != respType {
+				return errs.New("method option type mismatch", "method", md.FullName(),
+					"want", reqType.String()+", "+respType.String(), "got", opt.req.String()+", "+opt.resp.String()).Wrap()
+			}
+		}
+		relativePath := conf.path
+		if relativePath == "" {
+			relativePath = snakeCase(name)
+		}
+		handlers := append(append([]gin.HandlerFunc{}, conf.middleware...), unaryHandler(rpc, reqType, conf))
+		group.Handle(http.MethodPost, relativePath, handlers...)
+		s.registry.Add(Route{
+			Method:   http.MethodPost,
+			Path:     joinPath(group, relativePath),
+			Summary:  string(md.FullName()),
+			Request:  reqType.Elem(),
+			Response: respType.Elem(),
+			Codec:    conf.codec,
+		})
+	}
+	return nil
+}
+
+// checkUnaryMethod verifies that rpc has the generated unary client signature for md and returns
+// its request type.
+func checkUnaryMethod(md protoreflect.MethodDescriptor, rpc reflect.Type) (reflect.Type, error) {
+	if rpc.NumIn() != 3 || !rpc.IsVariadic() || rpc.In(0) != contextType || rpc.In(2) != callOptionType ||
+		rpc.NumOut() != 2 || rpc.Out(1) != errorType {
+		return nil, errs.New("rpc client method is not unary", "method", md.FullName(), "type", rpc.String()).Wrap()
+	}
+	reqType, respType := rpc.In(1), rpc.Out(0)
+	if !reqType.Implements(messageType) || !respType.Implements(messageType) {
+		return nil, errs.New("rpc client method does not use proto messages", "method", md.FullName(), "type", rpc.String()).Wrap()
+	}
+	req := reflect.New(reqType.Elem()).Interface().(proto.Message)
+	if got := req.ProtoReflect().Descriptor().FullName(); got != md.Input().FullName() {
+		return nil, errs.New("rpc client request type mismatch", "method", md.FullName(), "want", md.Input().FullName(), "got", got).Wrap()
+	}
+	return reqType, nil
+}
+
+func unaryHandler(rpc reflect.Value, reqType reflect.Type, conf *methodConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if conf.codec != nil {
+			apiresp.SetGinCodec(c, conf.codec)
+		}
+		req := reflect.New(reqType.Elem()).Interface()
+		if err := bindRequest(c, req); err != nil {
+			apiresp.GinError(c, err)
+			return
+		}
+		for _, bindAfter := range conf.bindAfter {
+			if err := bindAfter(req); err != nil {
+				apiresp.GinError(c, err) // args option error
+				return
+			}
+		}
+		if err := checker.Validate(checkContext(c), req); err != nil {
+			apiresp.GinError(c, err) // args option error
+			return
+		}
+		out := rpc.Call([]reflect.Value{reflect.ValueOf(c), reflect.ValueOf(req)})
+		if err, _ := out[1].Interface().(error); err != nil {
+			apiresp.GinError(c, err) // rpc call failed
+			return
+		}
+		resp := out[0].Interface()
+		for _, respAfter := range conf.respAfter {
+			if err := respAfter(resp); err != nil {
+				apiresp.GinError(c, err) // resp option error
+				return
+			}
+		}
+		apiresp.GinSuccess(c, resp) // rpc call success
+	}
+}
+
+// snakeCase converts a Go or proto identifier such as GetUsersInfo to get_users_info.
+// Runs of upper case letters are kept together, so GetUserIDs becomes get_user_ids.
+func snakeCase(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 4)
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
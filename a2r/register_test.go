@@ -0,0 +1,109 @@
+package a2r
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type healthClient struct {
+	grpc_health_v1.HealthClient
+}
+
+func (healthClient) Check(_ context.Context, req *grpc_health_v1.HealthCheckRequest, _ ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.Service == "unknown" {
+		return nil, errs.ErrRecordNotFound.WrapMsg("service not found")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func TestRegisterService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	sd := grpc_health_v1.File_grpc_health_v1_health_proto.Services().ByName("Health")
+	var called []string
+	err := RegisterService(engine, sd, grpc_health_v1.HealthClient(healthClient{}),
+		WithMiddleware("Check", func(c *gin.Context) { called = append(called, "middleware") }),
+		WithMethodOption("Check", &Option[grpc_health_v1.HealthCheckRequest, grpc_health_v1.HealthCheckResponse]{
+			BindAfter: func(req *grpc_health_v1.HealthCheckRequest) error {
+				called = append(called, "bind:"+req.Service)
+				return nil
+			},
+			RespAfter: func(resp *grpc_health_v1.HealthCheckResponse) error {
+				called = append(called, "resp:"+resp.Status.String())
+				return nil
+			},
+		}),
+	)
+	assert.NoError(t, err)
+
+	routes := engine.Routes()
+	if assert.Len(t, routes, 1) {
+		assert.Equal(t, http.MethodPost, routes[0].Method)
+		assert.Equal(t, "/health/check", routes[0].Path)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/health/check", strings.NewReader(`{"service":"msg"}`)))
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":{"status":1}}`, w.Body.String())
+	assert.Equal(t, []string{"middleware", "bind:msg", "resp:SERVING"}, called)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/health/check", strings.NewReader(`{"service":"unknown"}`)))
+	assert.Contains(t, w.Body.String(), `"errCode":1004`)
+}
+
+func TestRegisterServiceOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	sd := grpc_health_v1.File_grpc_health_v1_health_proto.Services().ByName("Health")
+	reg := NewRegistry()
+	assert.NoError(t, RegisterService(engine, sd, healthClient{}, WithPrefix("/api/v1/health"), WithPath("Check", "ping"), WithRegistry(reg)))
+	assert.Equal(t, "/api/v1/health/ping", engine.Routes()[0].Path)
+	if routes := reg.Routes(); assert.Len(t, routes, 1) {
+		assert.Equal(t, "/api/v1/health/ping", routes[0].Path)
+		assert.Equal(t, "grpc.health.v1.Health.Check", routes[0].Summary)
+	}
+
+	assert.Error(t, RegisterService(gin.New(), sd, struct{}{}))
+}
+
+func TestRegisterServiceInvalidOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sd := grpc_health_v1.File_grpc_health_v1_health_proto.Services().ByName("Health")
+	for name, opt := range map[string]ServiceOption{
+		"path":       WithPath("Chek", "ping"),
+		"skip":       WithSkip("Check", "Chek"),
+		"middleware": WithMiddleware("Chek", func(*gin.Context) {}),
+		"option":     WithMethodOption("Chek", &Option[grpc_health_v1.HealthCheckRequest, grpc_health_v1.HealthCheckResponse]{}),
+	} {
+		engine := gin.New()
+		err := RegisterService(engine, sd, healthClient{}, opt)
+		assert.ErrorContains(t, err, "rpc service has no method", name)
+		assert.Empty(t, engine.Routes(), name)
+	}
+
+	err := RegisterService(gin.New(), sd, healthClient{},
+		WithMethodOption("Check", &Option[grpc_health_v1.HealthCheckResponse, grpc_health_v1.HealthCheckResponse]{}))
+	assert.ErrorContains(t, err, "method option type mismatch")
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"GetUsersInfo":     "get_users_info",
+		"GetUserIDs":       "get_user_ids",
+		"user":             "user",
+		"SetGroupInfoEx2":  "set_group_info_ex2",
+		"GetJoinedGroupV2": "get_joined_group_v2",
+	} {
+		assert.Equal(t, want, snakeCase(in), in)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2r
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"sync"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// Route describes an HTTP route bridged to an rpc method. Routes mounted by RegisterService and
+// Handle are recorded in their Registry and documented by OpenAPIHandler.
+type Route struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  reflect.Type
+	Response reflect.Type
+	// Codec is the codec selected for the route, nil means apiresp.DefaultCodec.
+	Codec apiresp.Codec
+}
+
+// Registry records the routes of one server for its OpenAPI document. A nil *Registry records
+// nothing, so routes can be mounted without being documented.
+type Registry struct {
+	mu   sync.RWMutex
+	list []Route
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add records a route, routes wired by hand are added so that they appear in the OpenAPI document.
+func (r *Registry) Add(route Route) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.list = append(r.list, route)
+}
+
+// Routes returns the recorded routes in registration order.
+func (r *Registry) Routes() []Route {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Route(nil), r.list...)
+}
+
+// Handle mounts a route calling rpc through Call and records it in reg for the OpenAPI document.
+func Handle[A, B, C any](router gin.IRouter, reg *Registry, httpMethod, relativePath string, rpc func(client C, ctx context.Context, req *A, options ...grpc.CallOption) (*B, error), client C, opts ...*Option[A, B]) {
+	router.Handle(httpMethod, relativePath, func(c *gin.Context) {
+		Call(rpc, client, c, opts...)
+	})
+	route := Route{
+		Method:   httpMethod,
+		Path:     joinPath(router, relativePath),
+		Request:  reflect.TypeOf((*A)(nil)).Elem(),
+		Response: reflect.TypeOf((*B)(nil)).Elem(),
+	}
+	for _, opt := range opts {
+		if opt.Codec != nil {
+			route.Codec = opt.Codec
+		}
+	}
+	reg.Add(route)
+}
+
+func joinPath(router gin.IRouter, relativePath string) string {
+	if group, ok := router.(interface{ BasePath() string }); ok {
+		return path.Join(group.BasePath(), relativePath)
+	}
+	return path.Join("/", relativePath)
+}
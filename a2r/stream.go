@@ -0,0 +1,184 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2r
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Meikwei/go-tools/apiresp"
+	"github.com/Meikwei/go-tools/checker"
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const (
+	sseContentType    = "text/event-stream"
+	ndjsonContentType = "application/x-ndjson"
+
+	sseEventMessage = "message"
+	sseEventError   = "error"
+)
+
+// ServerStream is the receiving side of a server-streaming RPC. It is satisfied by
+// grpc.ServerStreamingClient[B] and by the per-method client interfaces of older generated code.
+type ServerStream[B any] interface {
+	Recv() (*B, error)
+	grpc.ClientStream
+}
+
+// StreamFormat selects how each streamed message is framed on the wire.
+type StreamFormat int
+
+const (
+	// StreamAuto picks SSE when the client accepts text/event-stream, NDJSON otherwise.
+	StreamAuto StreamFormat = iota
+	StreamSSE
+	StreamNDJSON
+)
+
+// StreamOption configures a single Stream call.
+type StreamOption[A, B any] struct {
+	Option[A, B]
+	Format StreamFormat
+}
+
+// Stream is the server-streaming counterpart of Call. Every message received from the rpc is
+// written as one apiresp.ApiResponse, either as an SSE event or as one NDJSON line. A client
+// disconnect cancels the rpc context, and an error after the stream has started is written as a
+// final ApiResponse built by apiresp.ParseError.
+func Stream[A, B, C any, S ServerStream[B]](rpc func(client C, ctx context.Context, req *A, options ...grpc.CallOption) (S, error), client C, c *gin.Context, opts ...*StreamOption[A, B]) {
+	for _, opt := range opts {
+		if opt.Codec != nil {
+			apiresp.SetGinCodec(c, opt.Codec)
+		}
+	}
+	req, err := ParseRequestNotCheck[A](c)
+	if err != nil {
+		apiresp.GinError(c, err)
+		return
+	}
+	for _, opt := range opts {
+		if opt.BindAfter == nil {
+			continue
+		}
+		if err := opt.BindAfter(req); err != nil {
+			apiresp.GinError(c, err) // args option error
+			return
+		}
+	}
+	if err := checker.Validate(checkContext(c), req); err != nil {
+		apiresp.GinError(c, err) // args option error
+		return
+	}
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+	stop := context.AfterFunc(c.Request.Context(), cancel)
+	defer stop()
+	stream, err := rpc(client, ctx, req)
+	if err != nil {
+		apiresp.GinError(c, err) // rpc call failed
+		return
+	}
+	w := newStreamWriter(c, streamFormat(c, opts))
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				w.writeError(err) // rpc stream failed
+			}
+			return
+		}
+		for _, opt := range opts {
+			if opt.RespAfter == nil {
+				continue
+			}
+			if err := opt.RespAfter(resp); err != nil {
+				w.writeError(err) // resp option error
+				return
+			}
+		}
+		if err := w.write(sseEventMessage, apiresp.ApiSuccess(resp)); err != nil {
+			return // client went away
+		}
+	}
+}
+
+func streamFormat[A, B any](c *gin.Context, opts []*StreamOption[A, B]) StreamFormat {
+	for _, opt := range opts {
+		if opt.Format != StreamAuto {
+			return opt.Format
+		}
+	}
+	if strings.Contains(c.GetHeader("Accept"), sseContentType) {
+		return StreamSSE
+	}
+	return StreamNDJSON
+}
+
+type streamWriter struct {
+	c      *gin.Context
+	format StreamFormat
+	codec  apiresp.Codec
+}
+
+func newStreamWriter(c *gin.Context, format StreamFormat) *streamWriter {
+	header := c.Writer.Header()
+	if format == StreamSSE {
+		header.Set("Content-Type", sseContentType)
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+	} else {
+		header.Set("Content-Type", ndjsonContentType)
+	}
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	return &streamWriter{c: c, format: format, codec: apiresp.GinCodec(c)}
+}
+
+func (w *streamWriter) write(event string, resp *apiresp.ApiResponse) error {
+	data, err := jsonutil.JsonMarshal(resp.WithCodec(w.codec))
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	if w.format == StreamSSE {
+		buf = make([]byte, 0, len(data)+len(event)+16)
+		buf = append(buf, "event: "...)
+		buf = append(buf, event...)
+		buf = append(buf, "\ndata: "...)
+		buf = append(buf, data...)
+		buf = append(buf, "\n\n"...)
+	} else {
+		buf = append(data, '\n')
+	}
+	if _, err := w.c.Writer.Write(buf); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func (w *streamWriter) writeError(err error) {
+	resp := apiresp.ParseError(err)
+	apiresp.Localize(w.c.Request.Context(), w.c.GetHeader("Accept-Language"), err, resp)
+	_ = w.write(sseEventError, resp)
+}
@@ -0,0 +1,132 @@
+package a2r
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type streamReq struct {
+	Count int `json:"count"`
+}
+
+type streamResp struct {
+	Seq int `json:"seq"`
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	msgs []*streamResp
+	err  error
+}
+
+func (s *fakeStream) Recv() (*streamResp, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.msgs) == 0 {
+		return nil, s.err
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+type fakeStreamClient struct {
+	err error
+}
+
+func (f fakeStreamClient) Feed(ctx context.Context, req *streamReq, _ ...grpc.CallOption) (*fakeStream, error) {
+	s := &fakeStream{ctx: ctx, err: io.EOF}
+	for i := 0; i < req.Count; i++ {
+		s.msgs = append(s.msgs, &streamResp{Seq: i})
+	}
+	if f.err != nil {
+		s.err = f.err
+	}
+	return s, nil
+}
+
+func serveStream(client fakeStreamClient, accept, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/feed", func(c *gin.Context) {
+		Stream(fakeStreamClient.Feed, client, c)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/feed", strings.NewReader(body))
+	req.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	w := serveStream(fakeStreamClient{}, "application/json", `{"count":3}`)
+	assert.Equal(t, ndjsonContentType, w.Header().Get("Content-Type"))
+	var lines []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Equal(t, []string{
+		`{"errCode":0,"errMsg":"","errDlt":"","data":{"seq":0}}`,
+		`{"errCode":0,"errMsg":"","errDlt":"","data":{"seq":1}}`,
+		`{"errCode":0,"errMsg":"","errDlt":"","data":{"seq":2}}`,
+	}, lines)
+}
+
+func TestStreamSSEError(t *testing.T) {
+	w := serveStream(fakeStreamClient{err: errs.ErrNoPermission.Wrap()}, sseContentType, `{"count":1}`)
+	assert.Equal(t, sseContentType, w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event: message\ndata: {\"errCode\":0,\"errMsg\":\"\",\"errDlt\":\"\",\"data\":{\"seq\":0}}\n\n")
+	assert.Contains(t, body, "event: error\ndata: {\"errCode\":1002,")
+}
+
+type hangingStream struct {
+	grpc.ClientStream
+	ctx        context.Context
+	disconnect context.CancelFunc
+	sent       bool
+}
+
+func (s *hangingStream) Recv() (*streamResp, error) {
+	if !s.sent {
+		s.sent = true
+		return &streamResp{}, nil
+	}
+	s.disconnect()
+	select {
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	case <-time.After(time.Second):
+		return nil, errs.New("rpc context not canceled")
+	}
+}
+
+func TestStreamClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	reqCtx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	engine.POST("/feed", func(c *gin.Context) {
+		Stream(func(_ fakeStreamClient, ctx context.Context, _ *streamReq, _ ...grpc.CallOption) (*hangingStream, error) {
+			return &hangingStream{ctx: ctx, disconnect: disconnect}, nil
+		}, fakeStreamClient{}, c)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/feed", strings.NewReader(`{}`)).WithContext(reqCtx)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, `{"errCode":0,"errMsg":"","errDlt":"","data":{"seq":0}}`+"\n", w.Body.String())
+}
@@ -0,0 +1,127 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiresp
+
+import (
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ginCodecKey is the gin context key holding the Codec selected for the current route.
+const ginCodecKey = "apiresp.codec"
+
+// Codec encodes the Data of an ApiResponse and decodes JSON request bodies.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes with encoding/json through jsonutil.
+var JSONCodec Codec = jsonCodec{}
+
+var defaultCodec = JSONCodec
+
+// SetDefaultCodec replaces the codec used when a route does not select one. It is meant to be
+// called once during startup.
+func SetDefaultCodec(codec Codec) {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	defaultCodec = codec
+}
+
+// DefaultCodec returns the codec used when a route does not select one.
+func DefaultCodec() Codec {
+	return defaultCodec
+}
+
+// SetGinCodec selects the codec used for the request and response of c.
+func SetGinCodec(c *gin.Context, codec Codec) {
+	c.Set(ginCodecKey, codec)
+}
+
+// GinCodec returns the codec selected for c, or the default codec.
+func GinCodec(c *gin.Context) Codec {
+	if v, ok := c.Get(ginCodecKey); ok {
+		if codec, ok := v.(Codec); ok && codec != nil {
+			return codec
+		}
+	}
+	return defaultCodec
+}
+
+// CodecHandler returns a gin middleware selecting codec for every route it is attached to.
+func CodecHandler(codec Codec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		SetGinCodec(c, codec)
+		c.Next()
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return jsonutil.JsonMarshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return jsonutil.JsonUnmarshal(data, v)
+}
+
+// ProtoJSONCodec encodes proto.Message values with protojson, so that wrapper types, enums,
+// oneof fields, 64-bit integers and well-known types follow the proto3 JSON mapping. Other
+// values fall back to JSONCodec.
+type ProtoJSONCodec struct {
+	MarshalOptions   protojson.MarshalOptions
+	UnmarshalOptions protojson.UnmarshalOptions
+}
+
+// NewProtoJSONCodec creates a ProtoJSONCodec. useProtoNames emits the proto field names instead
+// of the lowerCamelCase JSON names, emitUnpopulated emits fields holding zero values. Unknown
+// fields in request bodies are ignored, as with encoding/json.
+func NewProtoJSONCodec(useProtoNames, emitUnpopulated bool) *ProtoJSONCodec {
+	return &ProtoJSONCodec{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames:   useProtoNames,
+			EmitUnpopulated: emitUnpopulated,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	}
+}
+
+func (p *ProtoJSONCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return JSONCodec.Marshal(v)
+	}
+	data, err := p.MarshalOptions.Marshal(m)
+	return data, errs.Wrap(err)
+}
+
+func (p *ProtoJSONCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return JSONCodec.Unmarshal(data, v)
+	}
+	if err := p.UnmarshalOptions.Unmarshal(data, m); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
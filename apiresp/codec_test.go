@@ -0,0 +1,54 @@
+package apiresp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoJSONCodecMarshal(t *testing.T) {
+	codec := NewProtoJSONCodec(false, false)
+
+	data, err := jsonutil.JsonMarshal(ApiSuccess(wrapperspb.Int64(1 << 60)).WithCodec(codec))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":"1152921504606846976"}`, string(data))
+
+	ts := timestamppb.New(time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC))
+	data, err = jsonutil.JsonMarshal(ApiSuccess(ts).WithCodec(codec))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":"2024-05-08T12:00:00Z"}`, string(data))
+
+	data, err = jsonutil.JsonMarshal(ApiSuccess(map[string]int{"a": 1}).WithCodec(codec))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":{"a":1}}`, string(data))
+}
+
+func TestProtoJSONCodecUnmarshal(t *testing.T) {
+	codec := NewProtoJSONCodec(false, false)
+
+	var v structpb.Struct
+	assert.NoError(t, codec.Unmarshal([]byte(`{"name":"x","n":1}`), &v))
+	assert.Equal(t, "x", v.Fields["name"].GetStringValue())
+
+	var ts timestamppb.Timestamp
+	assert.Error(t, codec.Unmarshal([]byte(`"not a time"`), &ts))
+
+	var m map[string]int
+	assert.NoError(t, codec.Unmarshal([]byte(`{"a":1}`), &m))
+	assert.Equal(t, map[string]int{"a": 1}, m)
+}
+
+func TestDefaultCodec(t *testing.T) {
+	defer SetDefaultCodec(nil)
+	assert.Equal(t, JSONCodec, DefaultCodec())
+
+	SetDefaultCodec(NewProtoJSONCodec(false, false))
+	data, err := jsonutil.JsonMarshal(ApiSuccess(wrapperspb.String("v")))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":"v"}`, string(data))
+}
@@ -0,0 +1,300 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiresp
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/utils/jsonutil"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	MIMEJSON     = "application/json"
+	MIMEProtobuf = "application/x-protobuf"
+	MIMEMsgPack  = "application/x-msgpack"
+)
+
+// ErrUnsupportedData is returned by an Encoder that cannot encode the Data of a response, the
+// response is then written as JSON.
+var ErrUnsupportedData = errs.New("response data not supported by encoder")
+
+// Encoder writes an ApiResponse in one wire format and decodes request bodies of that format.
+type Encoder interface {
+	ContentType() string
+	Encode(resp *ApiResponse) ([]byte, error)
+	Decode(data []byte, v any) error
+}
+
+var (
+	JSONEncoder     Encoder = jsonEncoder{}
+	ProtobufEncoder Encoder = protobufEncoder{}
+	MsgPackEncoder  Encoder = msgPackEncoder{}
+)
+
+var (
+	encoderLock sync.RWMutex
+	encoders    = map[string]Encoder{
+		MIMEJSON:               JSONEncoder,
+		MIMEProtobuf:           ProtobufEncoder,
+		"application/protobuf": ProtobufEncoder,
+		MIMEMsgPack:            MsgPackEncoder,
+		"application/msgpack":  MsgPackEncoder,
+	}
+)
+
+// RegisterEncoder makes enc available for the given media types, replacing previous encoders.
+func RegisterEncoder(enc Encoder, mediaTypes ...string) {
+	encoderLock.Lock()
+	defer encoderLock.Unlock()
+	for _, mediaType := range mediaTypes {
+		encoders[strings.ToLower(mediaType)] = enc
+	}
+}
+
+// EncoderFor returns the encoder registered for the media type of a Content-Type header value.
+func EncoderFor(contentType string) (Encoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+	enc, ok := encoders[mediaType]
+	return enc, ok
+}
+
+// NegotiateEncoder picks the registered encoder preferred by an Accept header value,
+// honouring q-values. JSON is used when nothing registered is acceptable.
+func NegotiateEncoder(accept string) Encoder {
+	if accept == "" {
+		return JSONEncoder
+	}
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+	for _, c := range candidates {
+		if enc, ok := encoders[c.mediaType]; ok {
+			return enc
+		}
+		if c.mediaType == "*/*" || c.mediaType == "application/*" {
+			return JSONEncoder
+		}
+	}
+	return JSONEncoder
+}
+
+// encodeResponse encodes resp with enc, falling back to JSON when enc does not support the data.
+func encodeResponse(enc Encoder, resp *ApiResponse) (Encoder, []byte, error) {
+	data, err := enc.Encode(resp)
+	if err != nil && enc != JSONEncoder && errs.Unwrap(err) == ErrUnsupportedData {
+		enc = JSONEncoder
+		data, err = enc.Encode(resp)
+	}
+	return enc, data, err
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonEncoder) Encode(resp *ApiResponse) ([]byte, error) {
+	return jsonutil.JsonMarshal(resp)
+}
+
+func (jsonEncoder) Decode(data []byte, v any) error {
+	return defaultCodec.Unmarshal(data, v)
+}
+
+// protobufEncoder writes the apiresp.ApiResponse message defined in apiresp.proto, the Data is
+// carried as a google.protobuf.Any and must be a proto.Message.
+type protobufEncoder struct{}
+
+const (
+	protoErrCodeField = 1
+	protoErrMsgField  = 2
+	protoErrDltField  = 3
+	protoDataField    = 4
+)
+
+func (protobufEncoder) ContentType() string {
+	return MIMEProtobuf
+}
+
+func (protobufEncoder) Encode(resp *ApiResponse) ([]byte, error) {
+	var data []byte
+	if resp.Data != nil {
+		if format, ok := resp.Data.(ApiFormat); ok {
+			format.ApiFormat()
+		}
+		m, ok := resp.Data.(proto.Message)
+		if !ok {
+			return nil, ErrUnsupportedData.Wrap()
+		}
+		a, err := anypb.New(m)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "anypb.New failed")
+		}
+		if data, err = proto.Marshal(a); err != nil {
+			return nil, errs.WrapMsg(err, "proto.Marshal failed")
+		}
+	}
+	var b []byte
+	if resp.ErrCode != 0 {
+		b = protowire.AppendTag(b, protoErrCodeField, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(int32(resp.ErrCode))))
+	}
+	if resp.ErrMsg != "" {
+		b = protowire.AppendTag(b, protoErrMsgField, protowire.BytesType)
+		b = protowire.AppendString(b, resp.ErrMsg)
+	}
+	if resp.ErrDlt != "" {
+		b = protowire.AppendTag(b, protoErrDltField, protowire.BytesType)
+		b = protowire.AppendString(b, resp.ErrDlt)
+	}
+	if data != nil {
+		b = protowire.AppendTag(b, protoDataField, protowire.BytesType)
+		b = protowire.AppendBytes(b, data)
+	}
+	return b, nil
+}
+
+func (protobufEncoder) Decode(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return errs.ErrArgs.WrapMsg("protobuf request body requires a proto message")
+	}
+	if err := proto.Unmarshal(data, m); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
+
+// UnmarshalProtoResponse decodes a response written by ProtobufEncoder. The returned ApiResponse
+// has no Data, the data message is returned as a google.protobuf.Any.
+func UnmarshalProtoResponse(b []byte) (*ApiResponse, *anypb.Any, error) {
+	resp := &ApiResponse{}
+	var data *anypb.Any
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, nil, errs.Wrap(protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch {
+		case num == protoErrCodeField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, nil, errs.Wrap(protowire.ParseError(n))
+			}
+			resp.ErrCode = int(int32(v))
+			b = b[n:]
+		case (num == protoErrMsgField || num == protoErrDltField || num == protoDataField) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, nil, errs.Wrap(protowire.ParseError(n))
+			}
+			switch num {
+			case protoErrMsgField:
+				resp.ErrMsg = string(v)
+			case protoErrDltField:
+				resp.ErrDlt = string(v)
+			default:
+				data = &anypb.Any{}
+				if err := proto.Unmarshal(v, data); err != nil {
+					return nil, nil, errs.WrapMsg(err, "proto.Unmarshal failed")
+				}
+			}
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, nil, errs.Wrap(protowire.ParseError(n))
+			}
+			b = b[n:]
+		}
+	}
+	return resp, data, nil
+}
+
+// msgPackEncoder writes the ApiResponse with MessagePack, the Data fields are named after their
+// json tags as with JSON.
+type msgPackEncoder struct{}
+
+var msgPackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+type msgPackResponse struct {
+	ErrCode int    `codec:"errCode"`
+	ErrMsg  string `codec:"errMsg"`
+	ErrDlt  string `codec:"errDlt"`
+	Data    any    `codec:"data,omitempty"`
+}
+
+func (msgPackEncoder) ContentType() string {
+	return MIMEMsgPack
+}
+
+func (msgPackEncoder) Encode(resp *ApiResponse) ([]byte, error) {
+	if resp.Data != nil {
+		if format, ok := resp.Data.(ApiFormat); ok {
+			format.ApiFormat()
+		}
+	}
+	var b []byte
+	err := codec.NewEncoderBytes(&b, msgPackHandle).Encode(&msgPackResponse{
+		ErrCode: resp.ErrCode,
+		ErrMsg:  resp.ErrMsg,
+		ErrDlt:  resp.ErrDlt,
+		Data:    resp.Data,
+	})
+	return b, errs.Wrap(err)
+}
+
+func (msgPackEncoder) Decode(data []byte, v any) error {
+	if err := codec.NewDecoderBytes(data, msgPackHandle).Decode(v); err != nil {
+		return errs.ErrArgs.WrapMsg(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package apiresp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNegotiateEncoder(t *testing.T) {
+	tests := map[string]Encoder{
+		"":                       JSONEncoder,
+		"*/*":                    JSONEncoder,
+		"text/html":              JSONEncoder,
+		"application/x-protobuf": ProtobufEncoder,
+		"application/json;q=0.9, application/x-protobuf": ProtobufEncoder,
+		"application/x-protobuf;q=0.5, application/json": JSONEncoder,
+		"application/msgpack":                            MsgPackEncoder,
+		"application/x-protobuf;q=0, */*":                JSONEncoder,
+	}
+	for accept, want := range tests {
+		assert.Equal(t, want, NegotiateEncoder(accept), accept)
+	}
+
+	enc, ok := EncoderFor("application/x-protobuf; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, ProtobufEncoder, enc)
+	_, ok = EncoderFor("text/plain")
+	assert.False(t, ok)
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	data, err := ProtobufEncoder.Encode(ApiSuccess(wrapperspb.String("hello")))
+	assert.NoError(t, err)
+	resp, a, err := UnmarshalProtoResponse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.ErrCode)
+	var v wrapperspb.StringValue
+	assert.NoError(t, a.UnmarshalTo(&v))
+	assert.Equal(t, "hello", v.Value)
+
+	data, err = ProtobufEncoder.Encode(ParseError(errs.ErrArgs.WithDetail("userID empty")))
+	assert.NoError(t, err)
+	resp, a, err = UnmarshalProtoResponse(data)
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+	assert.Equal(t, &ApiResponse{ErrCode: errs.ArgsError, ErrMsg: "ArgsError", ErrDlt: "userID empty"}, resp)
+
+	var req wrapperspb.Int64Value
+	body, _ := ProtobufEncoder.Encode(&ApiResponse{})
+	assert.NoError(t, ProtobufEncoder.Decode(body, &req))
+	assert.Error(t, ProtobufEncoder.Decode(nil, &struct{}{}))
+}
+
+// apiResponseDescriptor mirrors the ApiResponse message of apiresp.proto.
+func apiResponseDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("apiresp/apiresp.proto"),
+		Package:    proto.String("apiresp"),
+		Dependency: []string{"google/protobuf/any.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("ApiResponse"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("errCode", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				field("errMsg", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("errDlt", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("data", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Any"),
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return fd.Messages().ByName("ApiResponse")
+}
+
+func TestProtobufEncoderDescriptor(t *testing.T) {
+	md := apiResponseDescriptor(t)
+
+	// The descriptor must match the fields declared in apiresp.proto.
+	source, err := os.ReadFile("apiresp.proto")
+	assert.NoError(t, err)
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		typeName := fd.Kind().String()
+		if fd.Message() != nil {
+			typeName = string(fd.Message().FullName())
+		}
+		decl := fmt.Sprintf("%s %s = %d;", typeName, fd.Name(), fd.Number())
+		assert.True(t, strings.Contains(string(source), decl), decl)
+	}
+
+	data, err := ProtobufEncoder.Encode(&ApiResponse{ErrCode: -1, ErrMsg: "msg", ErrDlt: "dlt", Data: wrapperspb.String("hello")})
+	assert.NoError(t, err)
+	msg := dynamicpb.NewMessage(md)
+	assert.NoError(t, proto.Unmarshal(data, msg))
+	fields := md.Fields()
+	assert.EqualValues(t, -1, msg.Get(fields.ByName("errCode")).Int())
+	assert.Equal(t, "msg", msg.Get(fields.ByName("errMsg")).String())
+	assert.Equal(t, "dlt", msg.Get(fields.ByName("errDlt")).String())
+	a := &anypb.Any{}
+	assert.NoError(t, proto.Unmarshal(mustMarshal(t, msg.Get(fields.ByName("data")).Message().Interface()), a))
+	var v wrapperspb.StringValue
+	assert.NoError(t, a.UnmarshalTo(&v))
+	assert.Equal(t, "hello", v.Value)
+	assert.Empty(t, msg.GetUnknown())
+}
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	b, err := proto.Marshal(m)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestMsgPackEncoder(t *testing.T) {
+	type user struct {
+		UserID string `json:"userID"`
+	}
+	data, err := MsgPackEncoder.Encode(ApiSuccess(&user{UserID: "u1"}))
+	assert.NoError(t, err)
+	var out map[string]any
+	assert.NoError(t, codec.NewDecoderBytes(data, msgPackHandle).Decode(&out))
+	assert.EqualValues(t, 0, out["errCode"])
+	assert.Equal(t, map[any]any{"userID": "u1"}, out["data"])
+}
+
+func TestGinSuccessNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(accept string, data any) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Request.Header.Set("Accept", accept)
+		GinSuccess(c, data)
+		return w
+	}
+
+	w := serve(MIMEProtobuf, wrapperspb.Bool(true))
+	assert.Equal(t, MIMEProtobuf, w.Header().Get("Content-Type"))
+	_, a, err := UnmarshalProtoResponse(w.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Contains(t, a.TypeUrl, "google.protobuf.BoolValue")
+
+	// Go structs cannot be carried in a google.protobuf.Any, they fall back to JSON.
+	w = serve(MIMEProtobuf, map[string]int{"a": 1})
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":{"a":1}}`, w.Body.String())
+}
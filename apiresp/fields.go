@@ -0,0 +1,268 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiresp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldsParam is the query parameter listing the Data fields to keep, for example
+// ?fields=total,users.userID,users.nickname.
+const FieldsParam = "fields"
+
+const ginFieldsKey = "apiresp.fields"
+
+// FieldsHandler returns a gin middleware enabling the FieldsParam projection of the Data
+// written by GinSuccess on the routes it is attached to.
+func FieldsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ginFieldsKey, true)
+		c.Next()
+	}
+}
+
+// ParseFields splits a FieldsParam value into dot paths, ignoring empty entries.
+func ParseFields(s string) []string {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ginProjection applies the FieldsParam of c to data when FieldsHandler is enabled.
+func ginProjection(c *gin.Context, data any) (any, error) {
+	if !c.GetBool(ginFieldsKey) || data == nil {
+		return data, nil
+	}
+	fields := ParseFields(c.Query(FieldsParam))
+	if len(fields) == 0 {
+		return data, nil
+	}
+	return Project(data, GinCodec(c), fields)
+}
+
+// Project returns data marshaled with codec and pruned to the given dot paths. A path into a
+// slice applies to every element. Paths are checked against the names codec emits for the type
+// of data: the proto or JSON field names when a ProtoJSONCodec encodes a proto message, the json
+// tags otherwise. An unknown path is an errs.ErrArgs.
+func Project(data any, codec Codec, fields []string) (any, error) {
+	if codec == nil {
+		codec = defaultCodec
+	}
+	known := func(path []string) bool { return knownPath(reflect.TypeOf(data), path) }
+	if pc, ok := codec.(*ProtoJSONCodec); ok {
+		if m, ok := data.(proto.Message); ok {
+			md, useProtoNames := m.ProtoReflect().Descriptor(), pc.MarshalOptions.UseProtoNames
+			known = func(path []string) bool { return knownProtoPath(md, path, useProtoNames) }
+		}
+	}
+	tree := make(fieldTree)
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		if !known(path) {
+			return nil, errs.ErrArgs.WrapMsg("unknown field", "field", field)
+		}
+		tree.add(path)
+	}
+	if format, ok := data.(ApiFormat); ok {
+		format.ApiFormat()
+	}
+	raw, err := codec.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, errs.WrapMsg(err, "decode projected data failed")
+	}
+	return tree.prune(v), nil
+}
+
+// fieldTree is the set of requested paths, a nil subtree keeps the whole value.
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	sub, exists := t[path[0]]
+	if len(path) == 1 {
+		t[path[0]] = nil
+		return
+	}
+	if exists && sub == nil {
+		return // the whole value is already kept
+	}
+	if sub == nil {
+		sub = make(fieldTree)
+		t[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+func (t fieldTree) prune(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for key, sub := range t {
+			child, ok := val[key]
+			if !ok {
+				continue
+			}
+			if sub == nil {
+				out[key] = numbers(child)
+			} else {
+				out[key] = sub.prune(child)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, elem := range val {
+			out[i] = t.prune(elem)
+		}
+		return out
+	default:
+		return numbers(v)
+	}
+}
+
+// numbers replaces the json.Number values in v by int64, uint64 or float64, so that encoders
+// other than JSON, such as MessagePack, write them as numbers rather than strings.
+func numbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			return u
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return v
+	case map[string]any:
+		for key, child := range val {
+			val[key] = numbers(child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = numbers(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// knownPath reports whether path names a field of t as encoding/json encodes it, which covers
+// the json tags of generated proto messages too. Maps and interfaces accept any key.
+func knownPath(t reflect.Type, path []string) bool {
+	if t == nil {
+		return true
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Interface:
+		return true
+	case reflect.Struct:
+		field, ok := jsonField(t, path[0])
+		if !ok {
+			return false
+		}
+		return len(path) == 1 || knownPath(field.Type, path[1:])
+	default:
+		return false
+	}
+}
+
+// jsonField finds the field encoded by encoding/json under name, including embedded fields.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		tagName, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && tagName == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if f, ok := jsonField(ft, name); ok {
+					return f, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tagName == "" {
+			tagName = field.Name
+		}
+		if tagName == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// knownProtoPath reports whether path names a field of md as protojson encodes it, by the proto
+// names when useProtoNames is set and by the JSON names otherwise.
+func knownProtoPath(md protoreflect.MessageDescriptor, path []string, useProtoNames bool) bool {
+	fields := md.Fields()
+	var fd protoreflect.FieldDescriptor
+	if useProtoNames {
+		fd = fields.ByTextName(path[0])
+	} else {
+		fd = fields.ByJSONName(path[0])
+	}
+	if fd == nil {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	switch {
+	case fd.IsMap():
+		return true
+	case fd.Message() != nil:
+		switch fd.Message().FullName() {
+		case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.Any":
+			return true
+		}
+		return knownProtoPath(fd.Message(), path[1:], useProtoNames)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package apiresp
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type fieldsUser struct {
+	UserID   string `json:"userID"`
+	Nickname string `json:"nickname,omitempty"`
+	Ex       string `json:"ex"`
+}
+
+type fieldsResp struct {
+	Total int64         `json:"total"`
+	Users []*fieldsUser `json:"users"`
+}
+
+func TestProject(t *testing.T) {
+	data := &fieldsResp{Total: 2, Users: []*fieldsUser{{UserID: "u1", Nickname: "n1", Ex: "x"}, {UserID: "u2"}}}
+
+	v, err := Project(data, nil, []string{"total", "users.nickname", "users.userID"})
+	assert.NoError(t, err)
+	b, _ := json.Marshal(v)
+	assert.JSONEq(t, `{"total":2,"users":[{"userID":"u1","nickname":"n1"},{"userID":"u2"}]}`, string(b))
+
+	v, err = Project(data, nil, []string{"users", "users.ex"})
+	assert.NoError(t, err)
+	b, _ = json.Marshal(v)
+	assert.JSONEq(t, `{"users":[{"userID":"u1","nickname":"n1","ex":"x"},{"userID":"u2","ex":""}]}`, string(b))
+
+	_, err = Project(data, nil, []string{"users.phone"})
+	assert.Equal(t, errs.ArgsError, errs.Unwrap(err).(errs.CodeError).Code())
+	_, err = Project(data, nil, []string{"total.value"})
+	assert.Error(t, err)
+}
+
+func TestProjectProto(t *testing.T) {
+	data := &descriptorpb.DescriptorProto{
+		Name:  proto.String("User"),
+		Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("user_id"), JsonName: proto.String("userId")}},
+	}
+	v, err := Project(data, NewProtoJSONCodec(false, false), []string{"field.jsonName", "field.name"})
+	assert.NoError(t, err)
+	b, _ := json.Marshal(v)
+	assert.JSONEq(t, `{"field":[{"name":"user_id","jsonName":"userId"}]}`, string(b))
+
+	v, err = Project(data, NewProtoJSONCodec(true, false), []string{"field.json_name"})
+	assert.NoError(t, err)
+	b, _ = json.Marshal(v)
+	assert.JSONEq(t, `{"field":[{"json_name":"userId"}]}`, string(b))
+
+	// Paths follow the names the codec emits.
+	_, err = Project(data, NewProtoJSONCodec(false, false), []string{"field.json_name"})
+	assert.Error(t, err)
+	_, err = Project(data, NewProtoJSONCodec(true, false), []string{"field.jsonName"})
+	assert.Error(t, err)
+	v, err = Project(data, JSONCodec, []string{"field.json_name"})
+	assert.NoError(t, err)
+	b, _ = json.Marshal(v)
+	assert.JSONEq(t, `{"field":[{"json_name":"userId"}]}`, string(b))
+	_, err = Project(data, JSONCodec, []string{"field.jsonName"})
+	assert.Error(t, err)
+
+	_, err = Project(data, nil, []string{"field.phone"})
+	assert.Error(t, err)
+	_, err = Project(data, nil, []string{"name.value"})
+	assert.Error(t, err)
+}
+
+func TestProjectNumbers(t *testing.T) {
+	type numbers struct {
+		Count int64   `json:"count"`
+		Max   uint64  `json:"max"`
+		Ratio float64 `json:"ratio"`
+		List  []int   `json:"list"`
+	}
+	v, err := Project(&numbers{Count: -3, Max: math.MaxUint64, Ratio: 0.5, List: []int{1}}, nil, []string{"count", "max", "ratio", "list"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"count": int64(-3), "max": uint64(math.MaxUint64), "ratio": 0.5, "list": []any{int64(1)}}, v)
+
+	data, err := MsgPackEncoder.Encode(ApiSuccess(v))
+	assert.NoError(t, err)
+	var out map[string]any
+	assert.NoError(t, codec.NewDecoderBytes(data, msgPackHandle).Decode(&out))
+	assert.Equal(t, map[any]any{"count": int64(-3), "max": uint64(math.MaxUint64), "ratio": 0.5, "list": []any{int64(1)}}, out["data"])
+}
+
+func TestGinSuccessFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	data := &fieldsResp{Total: 1, Users: []*fieldsUser{{UserID: "u1", Nickname: "n1"}}}
+	engine.GET("/plain", func(c *gin.Context) { GinSuccess(c, data) })
+	engine.GET("/fields", FieldsHandler(), func(c *gin.Context) { GinSuccess(c, data) })
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := serve("/fields?fields=users.nickname")
+	assert.JSONEq(t, `{"errCode":0,"errMsg":"","errDlt":"","data":{"users":[{"nickname":"n1"}]}}`, w.Body.String())
+
+	w = serve("/plain?fields=users.nickname")
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	w = serve("/fields?fields=unknown")
+	var resp ApiResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errs.ArgsError, resp.ErrCode)
+}
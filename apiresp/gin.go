@@ -21,9 +21,28 @@ import (
 )
 
 func GinError(c *gin.Context, err error) {
-	c.JSON(http.StatusOK, ParseError(err))
+	resp := ParseError(err)
+	Localize(c.Request.Context(), c.GetHeader("Accept-Language"), err, resp)
+	ginWrite(c, ErrorStatus(resp), resp)
 }
 
+// GinSuccess writes data as a successful response. On routes using FieldsHandler the data is
+// pruned to the FieldsParam paths first, such responses are always written as JSON or MessagePack.
 func GinSuccess(c *gin.Context, data any) {
-	c.JSON(http.StatusOK, ApiSuccess(data))
+	data, err := ginProjection(c, data)
+	if err != nil {
+		GinError(c, err)
+		return
+	}
+	ginWrite(c, http.StatusOK, ApiSuccess(data))
+}
+
+// ginWrite writes resp with the encoder negotiated from the Accept header.
+func ginWrite(c *gin.Context, status int, resp *ApiResponse) {
+	enc, body, err := encodeResponse(NegotiateEncoder(c.GetHeader("Accept")), resp.WithCodec(GinCodec(c)))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "encode response error: "+err.Error())
+		return
+	}
+	c.Data(status, enc.ContentType(), body)
 }
@@ -15,26 +15,45 @@
 package apiresp
 
 import (
+	"context"
 	"net/http"
-
-	"github.com/Meikwei/go-tools/utils/jsonutil"
 )
 
-func httpJson(w http.ResponseWriter, data any) {
-	body, err := jsonutil.JsonMarshal(data)
+func httpWrite(w http.ResponseWriter, r *http.Request, status int, resp *ApiResponse) {
+	enc := JSONEncoder
+	if r != nil {
+		enc = NegotiateEncoder(r.Header.Get("Accept"))
+	}
+	enc, body, err := encodeResponse(enc, resp)
 	if err != nil {
-		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "encode response error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(status)
 	_, _ = w.Write(body)
 }
 
+// HttpError writes err, localized in the fallback language of the catalog set by SetCatalog.
 func HttpError(w http.ResponseWriter, err error) {
-	httpJson(w, ParseError(err))
+	resp := ParseError(err)
+	Localize(context.Background(), "", err, resp)
+	httpWrite(w, nil, ErrorStatus(resp), resp)
+}
+
+// HttpErrorWithRequest writes err, localized in the language negotiated for r and encoded
+// with the encoder negotiated from its Accept header.
+func HttpErrorWithRequest(w http.ResponseWriter, r *http.Request, err error) {
+	resp := ParseError(err)
+	Localize(r.Context(), r.Header.Get("Accept-Language"), err, resp)
+	httpWrite(w, r, ErrorStatus(resp), resp)
 }
 
 func HttpSuccess(w http.ResponseWriter, data any) {
-	httpJson(w, ApiSuccess(data))
+	httpWrite(w, nil, http.StatusOK, ApiSuccess(data))
+}
+
+// HttpSuccessWithRequest writes data with the encoder negotiated from the Accept header of r.
+func HttpSuccessWithRequest(w http.ResponseWriter, r *http.Request, data any) {
+	httpWrite(w, r, http.StatusOK, ApiSuccess(data))
 }
@@ -0,0 +1,197 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiresp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/Meikwei/go-tools/errs"
+	"golang.org/x/text/language"
+)
+
+// Catalog holds localized ErrMsg templates keyed by language and error code.
+// Templates use text/template and receive a MessageData.
+type Catalog struct {
+	lock     sync.RWMutex
+	fallback language.Tag
+	tags     []language.Tag
+	matcher  language.Matcher
+	messages map[language.Tag]map[int]*template.Template
+}
+
+// MessageData is the data passed to a Catalog template.
+type MessageData struct {
+	Code   int
+	Msg    string
+	Detail string
+	// Fields holds the key-values attached to the error through its wrap chain, formatted with
+	// fmt.Sprint. A missing key renders as an empty string.
+	Fields map[string]string
+}
+
+// NewCatalog creates a Catalog that falls back to the fallback language when no requested
+// language is supported.
+func NewCatalog(fallback language.Tag) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		tags:     []language.Tag{fallback},
+		messages: map[language.Tag]map[int]*template.Template{fallback: {}},
+	}
+}
+
+// Add registers the message template of code for the language tag.
+func (c *Catalog) Add(tag language.Tag, code int, text string) error {
+	tmpl, err := template.New(fmt.Sprintf("%s/%d", tag, code)).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return errs.WrapMsg(err, "parse message template failed", "lang", tag.String(), "code", code)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	m, ok := c.messages[tag]
+	if !ok {
+		m = make(map[int]*template.Template)
+		c.messages[tag] = m
+		c.tags = append(c.tags, tag)
+		c.matcher = nil
+	}
+	m[code] = tmpl
+	return nil
+}
+
+// AddMessages registers several message templates of the language tag at once.
+func (c *Catalog) AddMessages(tag language.Tag, messages map[int]string) error {
+	for code, text := range messages {
+		if err := c.Add(tag, code, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match returns the supported language closest to the preferred ones.
+func (c *Catalog) Match(preferred ...language.Tag) language.Tag {
+	c.lock.Lock()
+	if c.matcher == nil {
+		c.matcher = language.NewMatcher(c.tags)
+	}
+	matcher, tags := c.matcher, c.tags
+	c.lock.Unlock()
+	if len(preferred) == 0 {
+		return c.fallback
+	}
+	_, index, confidence := matcher.Match(preferred...)
+	if confidence == language.No {
+		return c.fallback
+	}
+	return tags[index]
+}
+
+// Message renders the template of code in the language tag, falling back to the catalog
+// fallback language. It reports false when neither has a template for code.
+func (c *Catalog) Message(tag language.Tag, code int, data *MessageData) (string, bool) {
+	c.lock.RLock()
+	tmpl, ok := c.messages[tag][code]
+	if !ok {
+		tmpl, ok = c.messages[c.fallback][code]
+	}
+	c.lock.RUnlock()
+	if !ok {
+		return "", false
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+var (
+	catalog     *Catalog
+	catalogLock sync.RWMutex
+)
+
+// SetCatalog enables localization of error responses, nil disables it.
+func SetCatalog(c *Catalog) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	catalog = c
+}
+
+func getCatalog() *Catalog {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+	return catalog
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a context selecting the language of error messages. It takes precedence
+// over the Accept-Language header.
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, tag)
+}
+
+// LanguageFromContext returns the language set by WithLanguage.
+func LanguageFromContext(ctx context.Context) (language.Tag, bool) {
+	if ctx == nil {
+		return language.Und, false
+	}
+	tag, ok := ctx.Value(languageKey{}).(language.Tag)
+	return tag, ok
+}
+
+// Localize replaces resp.ErrMsg with the catalog message of its code. The language is taken
+// from ctx, then negotiated from the acceptLanguage header value. ErrDlt is left untouched.
+func Localize(ctx context.Context, acceptLanguage string, err error, resp *ApiResponse) {
+	c := getCatalog()
+	if c == nil || resp.ErrCode == 0 {
+		return
+	}
+	var tag language.Tag
+	if t, ok := LanguageFromContext(ctx); ok {
+		tag = c.Match(t)
+	} else {
+		preferred, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+		tag = c.Match(preferred...)
+	}
+	data := &MessageData{
+		Code:   resp.ErrCode,
+		Msg:    resp.ErrMsg,
+		Detail: resp.ErrDlt,
+		Fields: errorFields(err),
+	}
+	if msg, ok := c.Message(tag, resp.ErrCode, data); ok {
+		resp.ErrMsg = msg
+	}
+}
+
+// errorFields collects the key-values of the errors in the chain of err, see errs.Fields. Errors
+// from errs.New, errs.WrapMsg, CodeError.WrapMsg and errs.FromStatus carry them, others add none.
+// The outermost value of a key wins.
+func errorFields(err error) map[string]string {
+	fields := make(map[string]string)
+	values := errs.Fields(err)
+	for i := 0; i+1 < len(values); i += 2 {
+		key := fmt.Sprint(values[i])
+		if _, exists := fields[key]; !exists {
+			fields[key] = fmt.Sprint(values[i+1])
+		}
+	}
+	return fields
+}
@@ -0,0 +1,88 @@
+package apiresp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func newTestCatalog(t *testing.T) *Catalog {
+	c := NewCatalog(language.English)
+	assert.NoError(t, c.AddMessages(language.English, map[int]string{
+		errs.ArgsError:         "Invalid argument {{.Fields.userID}}",
+		errs.NoPermissionError: "No permission",
+	}))
+	assert.NoError(t, c.AddMessages(language.SimplifiedChinese, map[int]string{
+		errs.ArgsError: "参数错误 {{.Fields.userID}}",
+	}))
+	return c
+}
+
+func TestCatalogMatch(t *testing.T) {
+	c := newTestCatalog(t)
+	assert.Equal(t, language.SimplifiedChinese, c.Match(language.MustParse("zh-CN")))
+	assert.Equal(t, language.English, c.Match(language.MustParse("en-GB")))
+	assert.Equal(t, language.English, c.Match(language.Japanese))
+	assert.Equal(t, language.English, c.Match())
+
+	_, ok := c.Message(language.English, errs.RecordNotFoundError, &MessageData{})
+	assert.False(t, ok)
+	msg, ok := c.Message(language.SimplifiedChinese, errs.NoPermissionError, &MessageData{})
+	assert.True(t, ok)
+	assert.Equal(t, "No permission", msg)
+}
+
+func TestLocalize(t *testing.T) {
+	SetCatalog(newTestCatalog(t))
+	defer SetCatalog(nil)
+
+	err := errs.ErrArgs.WrapMsg("check failed", "userID", "u1")
+
+	resp := ParseError(err)
+	Localize(context.Background(), "zh-CN,zh;q=0.9,en;q=0.8", err, resp)
+	assert.Equal(t, "参数错误 u1", resp.ErrMsg)
+	assert.Equal(t, err.Error(), resp.ErrDlt)
+
+	resp = ParseError(err)
+	Localize(WithLanguage(context.Background(), language.English), "zh-CN", err, resp)
+	assert.Equal(t, "Invalid argument u1", resp.ErrMsg)
+
+	resp = ParseError(errs.ErrRecordNotFound)
+	Localize(context.Background(), "en", errs.ErrRecordNotFound, resp)
+	assert.Equal(t, "RecordNotFoundError", resp.ErrMsg)
+}
+
+func TestErrorFields(t *testing.T) {
+	inner := errs.New("group not found", "groupID", "g1", "userID", "inner").Wrap()
+	err := errs.WrapMsg(inner, "get group failed", "userID", "u1", "count", 3)
+	assert.Equal(t, map[string]string{"groupID": "g1", "userID": "u1", "count": "3"}, errorFields(err))
+	assert.Empty(t, errorFields(errs.ErrArgs.Wrap()))
+
+	// Errors received from another service keep the key-values of the remote chain.
+	remote := errs.FromStatus(errs.ToStatus(err, "group"))
+	assert.Equal(t, map[string]string{"groupID": "g1", "userID": "u1", "count": "3"}, errorFields(remote))
+}
+
+func TestGinErrorLocalized(t *testing.T) {
+	SetCatalog(newTestCatalog(t))
+	defer SetCatalog(nil)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request.Header.Set("Accept-Language", "zh-Hans")
+	GinError(c, errs.ErrArgs.Wrap())
+
+	var resp ApiResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, errs.ArgsError, resp.ErrCode)
+	assert.Equal(t, "参数错误 ", resp.ErrMsg)
+}
@@ -35,6 +35,14 @@ type ApiResponse struct {
 	ErrMsg  string `json:"errMsg"`
 	ErrDlt  string `json:"errDlt"`
 	Data    any    `json:"data,omitempty"`
+
+	codec Codec
+}
+
+// WithCodec sets the codec used to encode Data, overriding the default codec.
+func (r *ApiResponse) WithCodec(codec Codec) *ApiResponse {
+	r.codec = codec
+	return r
 }
 
 func (r *ApiResponse) MarshalJSON() ([]byte, error) {
@@ -47,7 +55,11 @@ func (r *ApiResponse) MarshalJSON() ([]byte, error) {
 		if isAllFieldsPrivate(tmp.Data) {
 			tmp.Data = json.RawMessage(nil)
 		} else {
-			data, err := jsonutil.JsonMarshal(tmp.Data)
+			codec := tmp.codec
+			if codec == nil {
+				codec = defaultCodec
+			}
+			data, err := codec.Marshal(tmp.Data)
 			if err != nil {
 				return nil, err
 			}
@@ -82,6 +94,14 @@ func ApiSuccess(data any) *ApiResponse {
 	return &ApiResponse{Data: data}
 }
 
+// fieldLister is implemented by the CodeErrors listing per field failures, such as
+// *checker.FieldErrors.
+type fieldLister interface {
+	FieldList() any
+}
+
+// ParseError converts err to an error response. The failures of a fieldLister are listed in Data,
+// ErrDlt keeps their flattened summary.
 func ParseError(err error) *ApiResponse {
 	if err == nil {
 		return ApiSuccess(nil)
@@ -92,6 +112,9 @@ func ParseError(err error) *ApiResponse {
 		if resp.ErrDlt == "" {
 			resp.ErrDlt = err.Error()
 		}
+		if fl, ok := codeErr.(fieldLister); ok {
+			resp.Data = fl.FieldList()
+		}
 		return &resp
 	}
 	return &ApiResponse{ErrCode: errs.ServerInternalError, ErrMsg: err.Error()}
@@ -1,5 +1,24 @@
 package apiresp
 
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Meikwei/go-tools/checker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFieldErrors(t *testing.T) {
+	fe := &checker.FieldErrors{}
+	fe.Add("userID", "required", "is required")
+	fe.Add("level", "oneof", "must be one of [1 2]", "1", "2")
+	data, err := json.Marshal(ParseError(fe.Wrap()))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"errCode":1001,"errMsg":"ArgsError","errDlt":"userID is required; level must be one of [1 2]","data":[
+		{"field":"userID","rule":"required","message":"is required"},
+		{"field":"level","rule":"oneof","message":"must be one of [1 2]","params":["1","2"]}]}`, string(data))
+}
+
 // import (
 // 	"github.com/openimsdk/protocol/friend"
 // 	"github.com/openimsdk/protocol/wrapperspb"
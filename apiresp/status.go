@@ -0,0 +1,119 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiresp
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// StatusMode controls the HTTP status code written with error responses.
+type StatusMode int
+
+const (
+	// StatusAlwaysOK replies 200 OK and reports the error only in the body. It is the default.
+	StatusAlwaysOK StatusMode = iota
+	// StatusMapped replies the status returned by the StatusMapper for the error code.
+	StatusMapped
+)
+
+// StatusMapper returns the HTTP status code for an errs.CodeError code.
+type StatusMapper func(code int) int
+
+type codeStatus struct {
+	code   int
+	status int
+}
+
+var (
+	statusMode   = StatusAlwaysOK
+	statusMapper StatusMapper
+	statusLock   sync.RWMutex
+	// statusCodes is kept in registration order, DefaultStatusMapper checks the parents in it.
+	statusCodes = []codeStatus{
+		{errs.ServerInternalError, http.StatusInternalServerError},
+		{errs.ArgsError, http.StatusBadRequest},
+		{errs.NoPermissionError, http.StatusForbidden},
+		{errs.DuplicateKeyError, http.StatusConflict},
+		{errs.RecordNotFoundError, http.StatusNotFound},
+		{errs.TokenExpiredError, http.StatusUnauthorized},
+		{errs.TokenMalformedError, http.StatusUnauthorized},
+		{errs.TokenNotValidYetError, http.StatusUnauthorized},
+		{errs.TokenUnknownError, http.StatusUnauthorized},
+	}
+)
+
+// SetStatusMode switches between replying 200 OK for every error and replying mapped statuses.
+func SetStatusMode(mode StatusMode) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	statusMode = mode
+}
+
+// SetStatusMapper replaces DefaultStatusMapper, nil restores it.
+func SetStatusMapper(mapper StatusMapper) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	statusMapper = mapper
+}
+
+// RegisterStatus maps an error code to an HTTP status for DefaultStatusMapper. Registering a code
+// again replaces its status but keeps its place in the registration order.
+func RegisterStatus(code, status int) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	for i := range statusCodes {
+		if statusCodes[i].code == code {
+			statusCodes[i].status = status
+			return
+		}
+	}
+	statusCodes = append(statusCodes, codeStatus{code: code, status: status})
+}
+
+// DefaultStatusMapper looks the code up in the registered statuses, then in the codes related to
+// them through errs.DefaultCodeRelation, the first registered parent wins. Unknown codes are
+// treated as client errors.
+func DefaultStatusMapper(code int) int {
+	statusLock.RLock()
+	defer statusLock.RUnlock()
+	for _, s := range statusCodes {
+		if s.code == code {
+			return s.status
+		}
+	}
+	for _, s := range statusCodes {
+		if errs.DefaultCodeRelation.Is(s.code, code) {
+			return s.status
+		}
+	}
+	return http.StatusBadRequest
+}
+
+// ErrorStatus returns the HTTP status code to reply with for resp under the current StatusMode.
+func ErrorStatus(resp *ApiResponse) int {
+	statusLock.RLock()
+	mode, mapper := statusMode, statusMapper
+	statusLock.RUnlock()
+	if mode != StatusMapped || resp.ErrCode == 0 {
+		return http.StatusOK
+	}
+	if mapper == nil {
+		mapper = DefaultStatusMapper
+	}
+	return mapper(resp.ErrCode)
+}
@@ -0,0 +1,96 @@
+package apiresp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStatus(t *testing.T) {
+	defer SetStatusMode(StatusAlwaysOK)
+
+	assert.Equal(t, http.StatusOK, ErrorStatus(ParseError(errs.ErrArgs.Wrap())))
+
+	SetStatusMode(StatusMapped)
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errs.ErrArgs.WrapMsg("bad userID"), http.StatusBadRequest},
+		{errs.ErrNoPermission.Wrap(), http.StatusForbidden},
+		{errs.ErrRecordNotFound, http.StatusNotFound},
+		{errs.ErrTokenExpired.Wrap(), http.StatusUnauthorized},
+		{errs.ErrTokenUnknown, http.StatusUnauthorized},
+		{errors.New("boom"), http.StatusInternalServerError},
+		{errs.NewCodeError(20001, "GroupNotExist"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ErrorStatus(ParseError(tt.err)), "%v", tt.err)
+	}
+}
+
+// childRelation relates the codes added to it and falls back to the relation it replaced.
+type childRelation struct {
+	errs.CodeRelation
+	parents map[int][]int
+}
+
+func (r *childRelation) Add(codes ...int) error {
+	for _, code := range codes[1:] {
+		r.parents[code] = append(r.parents[code], codes[0])
+	}
+	return nil
+}
+
+func (r *childRelation) Is(parent, child int) bool {
+	for _, p := range r.parents[child] {
+		if p == parent {
+			return true
+		}
+	}
+	return r.CodeRelation.Is(parent, child)
+}
+
+func TestStatusMapper(t *testing.T) {
+	relation := errs.DefaultCodeRelation
+	statusLock.RLock()
+	codes := append([]codeStatus(nil), statusCodes...)
+	statusLock.RUnlock()
+	t.Cleanup(func() {
+		errs.DefaultCodeRelation = relation
+		statusLock.Lock()
+		statusCodes = codes
+		statusLock.Unlock()
+		SetStatusMapper(nil)
+		SetStatusMode(StatusAlwaysOK)
+	})
+	errs.DefaultCodeRelation = &childRelation{CodeRelation: relation, parents: make(map[int][]int)}
+	SetStatusMode(StatusMapped)
+
+	assert.NoError(t, errs.DefaultCodeRelation.Add(errs.RecordNotFoundError, 30001))
+	RegisterStatus(30002, http.StatusTooManyRequests)
+	assert.Equal(t, http.StatusNotFound, DefaultStatusMapper(30001))
+	assert.Equal(t, http.StatusTooManyRequests, DefaultStatusMapper(30002))
+
+	// 30003 has two registered parents, the first registered one wins every time.
+	RegisterStatus(30010, http.StatusServiceUnavailable)
+	assert.NoError(t, errs.DefaultCodeRelation.Add(30010, 30003))
+	assert.NoError(t, errs.DefaultCodeRelation.Add(errs.DuplicateKeyError, 30003))
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, http.StatusConflict, DefaultStatusMapper(30003))
+	}
+
+	SetStatusMapper(func(code int) int { return http.StatusTeapot })
+	w := httptest.NewRecorder()
+	HttpError(w, errs.ErrArgs.Wrap())
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	w = httptest.NewRecorder()
+	HttpSuccess(w, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -14,20 +14,69 @@
 
 package checker
 
-import "github.com/Meikwei/go-tools/errs"
+import (
+	"context"
+
+	"github.com/Meikwei/go-tools/errs"
+)
 
 type Checker interface {
 	Check() error
 }
 
-func Validate(args any) error {
-	if checker, ok := args.(Checker); ok {
-		if err := checker.Check(); err != nil {
-			if _, ok := errs.Unwrap(err).(errs.CodeError); ok {
-				return err
+// Validate checks the check tags of args, see TagName, and calls its Check method when args
+// implements Checker or ContextChecker. All argument failures are returned together as a
+// *FieldErrors. A Check error is an argument failure when it is a *FieldErrors or has exactly the
+// errs.ArgsError code, and for Checker also when it carries no code. Check errors with another
+// code, children of errs.ArgsError included, are returned as is, any other error is an
+// infrastructure failure returned as errs.ErrInternalServer.
+func Validate(ctx context.Context, args any) error {
+	fe := &FieldErrors{}
+	if err := validateTags(ctx, args, fe); err != nil {
+		return err
+	}
+	var err error
+	switch checker := args.(type) {
+	case Checker:
+		if err = checker.Check(); err != nil {
+			if _, ok := errs.Unwrap(err).(errs.CodeError); !ok {
+				// a plain Check error has always been reported as an argument failure
+				fe.Add("", "check", err.Error())
+				err = nil
 			}
-			return errs.ErrArgs.WrapMsg(err.Error())
 		}
+	case ContextChecker:
+		err = checker.Check(ctx)
+	}
+	if err != nil {
+		if err := addCheckError(fe, err); err != nil {
+			return err
+		}
+	}
+	if len(fe.Fields) == 0 {
+		return nil
+	}
+	return fe.Wrap()
+}
+
+// addCheckError merges a Check error into fe when it is an argument failure, otherwise it returns
+// the error Validate reports.
+func addCheckError(fe *FieldErrors, err error) error {
+	switch e := errs.Unwrap(err).(type) {
+	case *FieldErrors:
+		fe.Fields = append(fe.Fields, e.Fields...)
+	case errs.CodeError:
+		// child codes of ArgsError are more specific than a field failure, they pass through
+		if e.Code() != errs.ArgsError {
+			return err
+		}
+		msg := e.Detail()
+		if msg == "" {
+			msg = err.Error()
+		}
+		fe.Add("", "check", msg)
+	default:
+		return errs.ErrInternalServer.WrapMsg(err.Error())
 	}
 	return nil
 }
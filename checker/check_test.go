@@ -9,6 +9,7 @@
 package checker_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Meikwei/go-tools/checker"
@@ -54,7 +55,7 @@ func TestValidate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := checker.Validate(tt.arg)
+			err := checker.Validate(context.Background(), tt.arg)
 			if tt.wantError != nil {
 				assert.ErrorIs(t, err, tt.wantError)
 			} else {
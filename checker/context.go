@@ -0,0 +1,102 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// ContextChecker is implemented by requests whose validation needs the request context, for
+// example to look up a group or a user. Only a *FieldErrors or an error with exactly the
+// errs.ArgsError code is an argument failure. An error with another code is returned as is, and
+// any other error, such as a context or a storage error, is returned as errs.ErrInternalServer.
+type ContextChecker interface {
+	Check(ctx context.Context) error
+}
+
+// ContextRule is a check tag rule that may do I/O. It reports false when v fails the rule, a
+// non-nil error is an infrastructure failure that aborts the validation with
+// errs.ErrInternalServer. The context rules of a request run concurrently once the other rules
+// have been checked.
+type ContextRule func(ctx context.Context, v reflect.Value, param string) (bool, error)
+
+var contextRules = make(map[string]ContextRule)
+
+// RegisterContextRule makes a context rule available to check tags under name.
+func RegisterContextRule(name string, rule ContextRule) {
+	ruleLock.Lock()
+	defer ruleLock.Unlock()
+	contextRules[name] = rule
+}
+
+func getContextRule(name string) (ContextRule, bool) {
+	ruleLock.RLock()
+	defer ruleLock.RUnlock()
+	rule, ok := contextRules[name]
+	return rule, ok
+}
+
+// contextJob is a context rule to apply to a field.
+type contextJob struct {
+	path  string
+	rule  tagRule
+	value reflect.Value
+}
+
+// runContextRules applies the collected context rules concurrently and adds their failures to
+// fe in the order of the fields.
+func runContextRules(ctx context.Context, jobs []contextJob, fe *FieldErrors) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	type result struct {
+		ok  bool
+		err error
+	}
+	results := make([]result, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		rule, _ := getContextRule(job.rule.name)
+		wg.Add(1)
+		go func(i int, job contextJob) {
+			defer wg.Done()
+			ok, err := rule(ctx, job.value, job.rule.param)
+			results[i] = result{ok: ok, err: err}
+		}(i, job)
+	}
+	wg.Wait()
+	failed := make(map[string]struct{}, len(fe.Fields))
+	for _, f := range fe.Fields {
+		failed[f.Field] = struct{}{}
+	}
+	for i, res := range results {
+		job := jobs[i]
+		if res.err != nil {
+			return errs.ErrInternalServer.WrapMsg(res.err.Error(), "field", job.path, "rule", job.rule.name)
+		}
+		if _, ok := failed[job.path]; ok {
+			continue // only the first failure of a field is reported
+		}
+		if !res.ok {
+			failed[job.path] = struct{}{}
+			fe.Add(job.path, job.rule.name, "failed "+job.rule.name, ruleParams(job.rule)...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package checker_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/checker"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type operationIDKey struct{}
+
+var errGroupStore = errors.New("group store unavailable")
+
+func init() {
+	checker.RegisterContextRule("group_exists", func(ctx context.Context, v reflect.Value, _ string) (bool, error) {
+		if ctx.Value(operationIDKey{}) == nil {
+			return false, errs.New("missing operationID")
+		}
+		switch v.String() {
+		case "broken":
+			return false, errGroupStore
+		case "lost":
+			return false, errs.WrapMsg(errs.ErrRecordNotFound, "find group failed", "groupID", "lost")
+		case "g1", "g2":
+			return true, nil
+		default:
+			return false, nil
+		}
+	})
+}
+
+type joinGroupReq struct {
+	GroupID  string   `json:"groupID" check:"required,group_exists"`
+	GroupIDs []string `json:"groupIDs" check:"max=2"`
+	UserID   string   `json:"userID"`
+}
+
+func (r *joinGroupReq) Check(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errs.ErrInternalServer.WrapMsg("no deadline")
+	}
+	switch r.UserID {
+	case "banned":
+		return errs.ErrArgs.WithDetail("user is banned")
+	case "broken":
+		return errGroupStore
+	case "canceled":
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestValidateContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), operationIDKey{}, "op1"), time.Second)
+	defer cancel()
+
+	assert.NoError(t, checker.Validate(ctx, &joinGroupReq{GroupID: "g1"}))
+
+	err := checker.Validate(ctx, &joinGroupReq{GroupID: "g3", UserID: "banned"})
+	var fe *checker.FieldErrors
+	assert.ErrorAs(t, err, &fe)
+	assert.Equal(t, []*checker.FieldError{
+		{Field: "groupID", Rule: "group_exists", Message: "failed group_exists"},
+		{Rule: "check", Message: "user is banned"},
+	}, fe.Fields)
+
+	// the context rule is skipped once the field failed
+	err = checker.Validate(ctx, &joinGroupReq{})
+	assert.ErrorAs(t, err, &fe)
+	assert.Len(t, fe.Fields, 1)
+	assert.Equal(t, "required", fe.Fields[0].Rule)
+
+	// a rule error is an infrastructure failure whatever its code
+	err = checker.Validate(ctx, &joinGroupReq{GroupID: "broken"})
+	assert.ErrorIs(t, err, errs.ErrInternalServer)
+	assert.ErrorContains(t, err, errGroupStore.Error())
+	assert.NotErrorIs(t, err, errs.ErrArgs)
+
+	err = checker.Validate(ctx, &joinGroupReq{GroupID: "lost"})
+	assert.ErrorIs(t, err, errs.ErrInternalServer)
+	assert.NotErrorIs(t, err, errs.ErrRecordNotFound)
+	assert.Equal(t, []any{"field", "groupID", "rule", "group_exists"}, errs.Fields(err))
+
+	err = checker.Validate(context.Background(), &joinGroupReq{GroupID: "g1"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errs.ErrArgs)
+
+	// a Check error without a code is an infrastructure failure, not an argument failure
+	err = checker.Validate(ctx, &joinGroupReq{GroupID: "g1", UserID: "broken"})
+	assert.ErrorIs(t, err, errs.ErrInternalServer)
+	assert.NotErrorIs(t, err, errs.ErrArgs)
+
+	canceled, cancelNow := context.WithTimeout(context.WithValue(context.Background(), operationIDKey{}, "op1"), time.Second)
+	cancelNow()
+	err = checker.Validate(canceled, &joinGroupReq{GroupID: "g1", UserID: "canceled"})
+	assert.ErrorIs(t, err, errs.ErrInternalServer)
+	assert.NotErrorIs(t, err, errs.ErrArgs)
+}
@@ -0,0 +1,113 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// FieldError is one validation failure of a field.
+type FieldError struct {
+	Field   string   `json:"field"` // path such as users[0].email, empty for the whole request
+	Rule    string   `json:"rule"`
+	Message string   `json:"message"`
+	Params  []string `json:"params,omitempty"`
+}
+
+// FieldErrors collects the validation failures of a request. It is an errs.ArgsError CodeError
+// whose detail lists every failure.
+type FieldErrors struct {
+	Fields []*FieldError
+	detail string
+}
+
+// Add records a failure of field.
+func (e *FieldErrors) Add(field, rule, message string, params ...string) {
+	e.Fields = append(e.Fields, &FieldError{Field: field, Rule: rule, Message: message, Params: params})
+}
+
+// Err returns e when it holds failures, nil otherwise.
+func (e *FieldErrors) Err() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// FieldList returns the failures, apiresp lists them in the response data.
+func (e *FieldErrors) FieldList() any {
+	return e.Fields
+}
+
+func (e *FieldErrors) Code() int {
+	return errs.ArgsError
+}
+
+func (e *FieldErrors) Msg() string {
+	return errs.ErrArgs.Msg()
+}
+
+func (e *FieldErrors) Detail() string {
+	v := make([]string, 0, len(e.Fields)+1)
+	for _, f := range e.Fields {
+		switch {
+		case f.Field == "":
+			v = append(v, f.Message)
+		case f.Message == "":
+			v = append(v, f.Field)
+		default:
+			v = append(v, f.Field+" "+f.Message)
+		}
+	}
+	if e.detail != "" {
+		v = append(v, e.detail)
+	}
+	return strings.Join(v, "; ")
+}
+
+func (e *FieldErrors) WithDetail(detail string) errs.CodeError {
+	d := detail
+	if e.detail != "" {
+		d = e.detail + ", " + detail
+	}
+	return &FieldErrors{Fields: e.Fields, detail: d}
+}
+
+func (e *FieldErrors) Is(err error) bool {
+	codeErr, ok := errs.Unwrap(err).(errs.CodeError)
+	if !ok {
+		return false
+	}
+	return errs.DefaultCodeRelation.Is(errs.ArgsError, codeErr.Code())
+}
+
+func (e *FieldErrors) Wrap() error {
+	return errs.Wrap(e)
+}
+
+func (e *FieldErrors) WrapMsg(msg string, kv ...any) error {
+	return errs.WrapMsg(e, msg, kv...)
+}
+
+func (e *FieldErrors) Error() string {
+	v := []string{strconv.Itoa(e.Code()), e.Msg()}
+	if detail := e.Detail(); detail != "" {
+		v = append(v, detail)
+	}
+	return strings.Join(v, " ")
+}
@@ -0,0 +1,536 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// TagName is the struct tag holding the rules of a field, for example
+//
+//	Email string `json:"email" check:"required,email"`
+//	Level int    `json:"level" check:"omitempty,oneof=1 2 3"`
+//	Password2 string `json:"password2" check:"eqfield=Password"`
+//
+// Rules are separated by commas and take their parameter after "=". The built-in rules are:
+//
+//	required             the value is not the zero value
+//	omitempty            skip the other rules when the value is the zero value
+//	min=n, max=n, len=n  bounds of a number, or of the length of a string, slice or map
+//	oneof=a b c          the value is one of the space separated values
+//	email, url           the string is an email address or an absolute URL
+//	eqfield=F, nefield=F                       compare with the sibling field F
+//	gtfield=F, gtefield=F, ltfield=F, ltefield=F  order against the number or time.Time field F
+//	required_with=F      required when the sibling field F is not zero
+//	required_without=F   required when the sibling field F is zero
+//
+// Rules added with RegisterRule and RegisterContextRule are used by name. Nested structs, and
+// the elements of slices, arrays and maps, are checked recursively. Only the first failing rule of
+// a field is reported.
+const TagName = "check"
+
+// Rule reports whether the field value v satisfies a custom rule with the given parameter.
+// Pointers are dereferenced before the rule is called.
+type Rule func(v reflect.Value, param string) bool
+
+var (
+	ruleLock    sync.RWMutex
+	customRules = make(map[string]Rule)
+)
+
+// RegisterRule makes a custom rule available to check tags under name.
+func RegisterRule(name string, rule Rule) {
+	ruleLock.Lock()
+	defer ruleLock.Unlock()
+	customRules[name] = rule
+}
+
+func getRule(name string) (Rule, bool) {
+	ruleLock.RLock()
+	defer ruleLock.RUnlock()
+	rule, ok := customRules[name]
+	return rule, ok
+}
+
+// tagRule is one parsed rule of a check tag.
+type tagRule struct {
+	name  string
+	param string
+}
+
+type fieldInfo struct {
+	index     int
+	name      string // json name, used in field paths
+	rules     []tagRule
+	omitempty bool
+}
+
+type typeInfo struct {
+	fields []fieldInfo
+}
+
+var typeCache sync.Map // reflect.Type -> *typeInfo
+
+func structInfo(t reflect.Type) (*typeInfo, error) {
+	if info, ok := typeCache.Load(t); ok {
+		return info.(*typeInfo), nil
+	}
+	info := &typeInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := fieldInfo{index: i, name: sf.Name}
+		if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+			field.name = name
+		}
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			for _, s := range strings.Split(tag, ",") {
+				name, param, _ := strings.Cut(strings.TrimSpace(s), "=")
+				if name == "" {
+					continue
+				}
+				if name == "omitempty" {
+					field.omitempty = true
+					continue
+				}
+				if _, ok := builtinRules[name]; !ok {
+					_, custom := getRule(name)
+					if _, withContext := getContextRule(name); !custom && !withContext {
+						return nil, errs.New("unknown check rule", "type", t.String(), "field", sf.Name, "rule", name).Wrap()
+					}
+				}
+				if err := checkParam(t, sf, name, param); err != nil {
+					return nil, err
+				}
+				field.rules = append(field.rules, tagRule{name: name, param: param})
+			}
+		}
+		info.fields = append(info.fields, field)
+	}
+	actual, _ := typeCache.LoadOrStore(t, info)
+	return actual.(*typeInfo), nil
+}
+
+// checkParam rejects malformed rule parameters when the tag is parsed.
+func checkParam(t reflect.Type, sf reflect.StructField, name, param string) error {
+	switch name {
+	case "min", "max", "len":
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			if _, err := time.ParseDuration(param); err != nil {
+				return errs.New("invalid check rule parameter", "type", t.String(), "field", sf.Name, "rule", name, "param", param).Wrap()
+			}
+		}
+	case "eqfield", "nefield", "gtfield", "gtefield", "ltfield", "ltefield", "required_with", "required_without":
+		if _, ok := t.FieldByName(param); !ok {
+			return errs.New("unknown check rule field", "type", t.String(), "field", sf.Name, "rule", name, "param", param).Wrap()
+		}
+	}
+	return nil
+}
+
+// fieldLevel is the value a rule is applied to.
+type fieldLevel struct {
+	value  reflect.Value // dereferenced field value, invalid for a nil pointer
+	parent reflect.Value // struct holding the field
+	param  string
+}
+
+type builtinRule struct {
+	check   func(fl fieldLevel) bool
+	message func(fl fieldLevel) string
+}
+
+var builtinRules map[string]builtinRule
+
+func init() {
+	builtinRules = map[string]builtinRule{
+		"required": {
+			check:   func(fl fieldLevel) bool { return !isZero(fl.value) },
+			message: func(fieldLevel) string { return "is required" },
+		},
+		"min": {
+			check:   func(fl fieldLevel) bool { return compareParam(fl) >= 0 },
+			message: func(fl fieldLevel) string { return sizePrefix(fl) + "must be at least " + fl.param },
+		},
+		"max": {
+			check:   func(fl fieldLevel) bool { return compareParam(fl) <= 0 },
+			message: func(fl fieldLevel) string { return sizePrefix(fl) + "must be at most " + fl.param },
+		},
+		"len": {
+			check:   func(fl fieldLevel) bool { return compareParam(fl) == 0 },
+			message: func(fl fieldLevel) string { return sizePrefix(fl) + "must be " + fl.param },
+		},
+		"oneof": {
+			check: func(fl fieldLevel) bool {
+				s := valueString(fl.value)
+				for _, v := range strings.Fields(fl.param) {
+					if v == s {
+						return true
+					}
+				}
+				return false
+			},
+			message: func(fl fieldLevel) string { return "must be one of [" + fl.param + "]" },
+		},
+		"email": {
+			check: func(fl fieldLevel) bool {
+				s := valueString(fl.value)
+				addr, err := mail.ParseAddress(s)
+				return err == nil && addr.Address == s
+			},
+			message: func(fieldLevel) string { return "must be a valid email address" },
+		},
+		"url": {
+			check: func(fl fieldLevel) bool {
+				u, err := url.Parse(valueString(fl.value))
+				return err == nil && u.Scheme != "" && u.Host != ""
+			},
+			message: func(fieldLevel) string { return "must be a valid URL" },
+		},
+		"eqfield": {
+			check:   func(fl fieldLevel) bool { return reflect.DeepEqual(iface(fl.value), iface(sibling(fl))) },
+			message: func(fl fieldLevel) string { return "must equal " + fl.param },
+		},
+		"nefield": {
+			check:   func(fl fieldLevel) bool { return !reflect.DeepEqual(iface(fl.value), iface(sibling(fl))) },
+			message: func(fl fieldLevel) string { return "must not equal " + fl.param },
+		},
+		"gtfield": {
+			check:   func(fl fieldLevel) bool { c, ok := compareValues(fl.value, sibling(fl)); return ok && c > 0 },
+			message: func(fl fieldLevel) string { return "must be greater than " + fl.param },
+		},
+		"gtefield": {
+			check:   func(fl fieldLevel) bool { c, ok := compareValues(fl.value, sibling(fl)); return ok && c >= 0 },
+			message: func(fl fieldLevel) string { return "must be greater than or equal to " + fl.param },
+		},
+		"ltfield": {
+			check:   func(fl fieldLevel) bool { c, ok := compareValues(fl.value, sibling(fl)); return ok && c < 0 },
+			message: func(fl fieldLevel) string { return "must be less than " + fl.param },
+		},
+		"ltefield": {
+			check:   func(fl fieldLevel) bool { c, ok := compareValues(fl.value, sibling(fl)); return ok && c <= 0 },
+			message: func(fl fieldLevel) string { return "must be less than or equal to " + fl.param },
+		},
+		"required_with": {
+			check:   func(fl fieldLevel) bool { return isZero(sibling(fl)) || !isZero(fl.value) },
+			message: func(fl fieldLevel) string { return "is required when " + fl.param + " is set" },
+		},
+		"required_without": {
+			check:   func(fl fieldLevel) bool { return !isZero(sibling(fl)) || !isZero(fl.value) },
+			message: func(fl fieldLevel) string { return "is required when " + fl.param + " is not set" },
+		},
+	}
+}
+
+// validation holds the state of the check tag walk of a request.
+type validation struct {
+	fe   *FieldErrors
+	jobs []contextJob // context rules, run after the walk
+}
+
+// validateTags adds the check tag failures of args to fe. The returned error reports a
+// malformed tag or a failing context rule.
+func validateTags(ctx context.Context, args any, fe *FieldErrors) error {
+	v := reflect.ValueOf(args)
+	if !v.IsValid() || !hasRules(v.Type()) {
+		return nil
+	}
+	vs := &validation{fe: fe}
+	if err := vs.walk(v, ""); err != nil {
+		return err
+	}
+	return runContextRules(ctx, vs.jobs, fe)
+}
+
+func (vs *validation) walk(v reflect.Value, path string) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if !hasRules(v.Type()) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return vs.walkStruct(v, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := vs.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := vs.walk(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key().Interface())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (vs *validation) walkStruct(v reflect.Value, path string) error {
+	info, err := structInfo(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range info.fields {
+		fv := v.Field(field.index)
+		fieldPath := field.name
+		if path != "" {
+			fieldPath = path + "." + field.name
+		}
+		value := indirect(fv)
+		if field.omitempty && isZero(value) {
+			continue
+		}
+		for _, rule := range field.rules {
+			if _, ok := getContextRule(rule.name); ok {
+				if value.IsValid() {
+					vs.jobs = append(vs.jobs, contextJob{path: fieldPath, rule: rule, value: value})
+				}
+				continue
+			}
+			fl := fieldLevel{value: value, parent: v, param: rule.param}
+			if msg, ok := applyRule(rule, fl); !ok {
+				vs.fe.Add(fieldPath, rule.name, msg, ruleParams(rule)...)
+				break // the next rules of the field would mostly repeat the failure
+			}
+		}
+		if hasRules(fv.Type()) {
+			if err := vs.walk(fv, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ruleParams(rule tagRule) []string {
+	switch {
+	case rule.param == "":
+		return nil
+	case rule.name == "oneof":
+		return strings.Fields(rule.param)
+	default:
+		return []string{rule.param}
+	}
+}
+
+func applyRule(rule tagRule, fl fieldLevel) (string, bool) {
+	if b, ok := builtinRules[rule.name]; ok {
+		if rule.name != "required" && !strings.HasPrefix(rule.name, "required_") && !fl.value.IsValid() {
+			return "", true // other rules only apply to set pointers
+		}
+		if b.check(fl) {
+			return "", true
+		}
+		return b.message(fl), false
+	}
+	custom, _ := getRule(rule.name)
+	if !fl.value.IsValid() || custom(fl.value, rule.param) {
+		return "", true
+	}
+	msg := "failed " + rule.name
+	if rule.param != "" {
+		msg += "=" + rule.param
+	}
+	return msg, false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var rulesCache sync.Map // reflect.Type -> bool
+
+// hasRules reports whether values of t may hold fields with check rules, so that the walk skips
+// the nested structs, slices and maps without any. Interfaces may hold anything and always do.
+func hasRules(t reflect.Type) bool {
+	if found, ok := rulesCache.Load(t); ok {
+		return found.(bool)
+	}
+	found := reachesRules(t, make(map[reflect.Type]bool))
+	rulesCache.Store(t, found)
+	return found
+}
+
+// reachesRules searches the types reachable from t, seen breaks the cycles of recursive types.
+func reachesRules(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return reachesRules(t.Elem(), seen)
+	case reflect.Struct:
+		if t == timeType {
+			return false
+		}
+		info, err := structInfo(t)
+		if err != nil {
+			return true // the walk reports the malformed tag
+		}
+		for _, field := range info.fields {
+			if len(field.rules) > 0 || reachesRules(t.Field(field.index).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func iface(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func sibling(fl fieldLevel) reflect.Value {
+	return indirect(fl.parent.FieldByName(fl.param))
+}
+
+func valueString(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(iface(v))
+}
+
+func sizePrefix(fl fieldLevel) string {
+	switch fl.value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return "length "
+	default:
+		return ""
+	}
+}
+
+// compareParam compares the number, or the length, of the field with the rule parameter.
+func compareParam(fl fieldLevel) int {
+	v := fl.value
+	var n float64
+	switch v.Kind() {
+	case reflect.String:
+		n = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return 0
+	}
+	p, err := strconv.ParseFloat(fl.param, 64)
+	if err != nil {
+		d, _ := time.ParseDuration(fl.param)
+		p = float64(d)
+	}
+	switch {
+	case n < p:
+		return -1
+	case n > p:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareValues orders two numbers, strings or time.Time values.
+func compareValues(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), true
+	}
+	x, ok1 := number(a)
+	y, ok2 := number(b)
+	if ok1 && ok2 {
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	return 0, false
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
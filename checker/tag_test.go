@@ -0,0 +1,210 @@
+package checker_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/checker"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type tagAddress struct {
+	City string `json:"city" check:"required"`
+}
+
+type tagUser struct {
+	UserID    string                 `json:"userID" check:"required,max=8"`
+	Email     string                 `json:"email" check:"omitempty,email"`
+	Level     int                    `json:"level" check:"oneof=1 2 3"`
+	Tags      []string               `json:"tags" check:"max=2"`
+	Password  string                 `json:"password" check:"min=6"`
+	Password2 string                 `json:"password2" check:"eqfield=Password"`
+	Start     time.Time              `json:"start"`
+	End       time.Time              `json:"end" check:"gtfield=Start"`
+	Phone     string                 `json:"phone" check:"required_without=Email"`
+	Address   *tagAddress            `json:"address"`
+	Friends   []*tagAddress          `json:"friends"`
+	Extra     map[string]*tagAddress `json:"extra"`
+}
+
+func validTagUser() *tagUser {
+	now := time.Now()
+	return &tagUser{
+		UserID:    "u1",
+		Email:     "u1@example.com",
+		Level:     2,
+		Password:  "secret",
+		Password2: "secret",
+		Start:     now,
+		End:       now.Add(time.Hour),
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(u *tagUser)
+		detail string
+	}{
+		{name: "valid", modify: func(*tagUser) {}},
+		{name: "required", modify: func(u *tagUser) { u.UserID = "" }, detail: "userID is required"},
+		{name: "max length", modify: func(u *tagUser) { u.UserID = "123456789" }, detail: "userID length must be at most 8"},
+		{name: "email", modify: func(u *tagUser) { u.Email = "nope" }, detail: "email must be a valid email address"},
+		{name: "oneof", modify: func(u *tagUser) { u.Level = 4 }, detail: "level must be one of [1 2 3]"},
+		{name: "slice length", modify: func(u *tagUser) { u.Tags = []string{"a", "b", "c"} }, detail: "tags length must be at most 2"},
+		{name: "eqfield", modify: func(u *tagUser) { u.Password2 = "secret2" }, detail: "password2 must equal Password"},
+		{name: "gtfield", modify: func(u *tagUser) { u.End = u.Start }, detail: "end must be greater than Start"},
+		{name: "required_without", modify: func(u *tagUser) { u.Email = "" }, detail: "phone is required when Email is not set"},
+		{name: "nested", modify: func(u *tagUser) { u.Address = &tagAddress{} }, detail: "address.city is required"},
+		{name: "slice element", modify: func(u *tagUser) { u.Friends = []*tagAddress{{City: "a"}, {}} }, detail: "friends[1].city is required"},
+		{name: "map value", modify: func(u *tagUser) { u.Extra = map[string]*tagAddress{"home": {}} }, detail: "extra[home].city is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := validTagUser()
+			tt.modify(u)
+			err := checker.Validate(context.Background(), u)
+			if tt.detail == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errs.ErrArgs)
+			assert.Equal(t, tt.detail, errs.Unwrap(err).(errs.CodeError).Detail())
+		})
+	}
+}
+
+type tagChecker struct {
+	Name string `json:"name" check:"required"`
+	err  error
+}
+
+func (c *tagChecker) Check() error { return c.err }
+
+func TestValidateTagsWithChecker(t *testing.T) {
+	err := checker.Validate(context.Background(), &tagChecker{})
+	assert.Equal(t, "name is required", errs.Unwrap(err).(errs.CodeError).Detail())
+
+	err = checker.Validate(context.Background(), &tagChecker{Name: "a", err: errs.New("check failed")})
+	assert.ErrorIs(t, err, errs.ErrArgs)
+}
+
+func TestRegisterRule(t *testing.T) {
+	checker.RegisterRule("prefix", func(v reflect.Value, param string) bool {
+		return strings.HasPrefix(v.String(), param)
+	})
+	type req struct {
+		ID string `json:"id" check:"prefix=u_"`
+	}
+	assert.NoError(t, checker.Validate(context.Background(), &req{ID: "u_1"}))
+	err := checker.Validate(context.Background(), &req{ID: "1"})
+	assert.Equal(t, "id failed prefix=u_", errs.Unwrap(err).(errs.CodeError).Detail())
+
+	type bad struct {
+		ID string `check:"unknown"`
+	}
+	err = checker.Validate(context.Background(), &bad{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errs.ErrArgs)
+}
+
+type aggregateReq struct {
+	Name  string `json:"name" check:"required"`
+	Level int    `json:"level" check:"oneof=1 2"`
+}
+
+func (r *aggregateReq) Check() error {
+	fe := &checker.FieldErrors{}
+	if r.Name == "admin" {
+		fe.Add("name", "reserved", "is reserved")
+	}
+	return fe.Err()
+}
+
+func TestValidateFieldErrors(t *testing.T) {
+	err := checker.Validate(context.Background(), &aggregateReq{})
+	var fe *checker.FieldErrors
+	assert.ErrorAs(t, err, &fe)
+	assert.ErrorIs(t, err, errs.ErrArgs)
+	assert.Equal(t, []*checker.FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "level", Rule: "oneof", Message: "must be one of [1 2]", Params: []string{"1", "2"}},
+	}, fe.Fields)
+	assert.Equal(t, "1001 ArgsError name is required; level must be one of [1 2]", fe.Error())
+
+	err = checker.Validate(context.Background(), &aggregateReq{Name: "admin", Level: 3})
+	assert.ErrorAs(t, err, &fe)
+	assert.Len(t, fe.Fields, 2)
+	assert.Equal(t, "reserved", fe.Fields[1].Rule)
+
+	assert.NoError(t, checker.Validate(context.Background(), &aggregateReq{Name: "a", Level: 1}))
+}
+
+// argsChild relates code to errs.ArgsError on top of the relation it replaced.
+type argsChild struct {
+	errs.CodeRelation
+	code int
+}
+
+func (r argsChild) Is(parent, child int) bool {
+	return (parent == errs.ArgsError && child == r.code) || r.CodeRelation.Is(parent, child)
+}
+
+func TestFieldErrorsIsChildCode(t *testing.T) {
+	relation := errs.DefaultCodeRelation
+	t.Cleanup(func() { errs.DefaultCodeRelation = relation })
+	errs.DefaultCodeRelation = argsChild{CodeRelation: relation, code: 31001}
+
+	err := checker.Validate(context.Background(), &aggregateReq{})
+	assert.ErrorIs(t, err, errs.NewCodeError(31001, "UserIDInvalid"))
+	assert.NotErrorIs(t, err, errs.NewCodeError(31002, "GroupIDInvalid"))
+	assert.NotErrorIs(t, err, errs.ErrRecordNotFound)
+
+	// a Check error with a child code of ArgsError is returned unchanged, not folded
+	child := errs.NewCodeError(31001, "UserIDInvalid").WithDetail("user u1")
+	err = checker.Validate(context.Background(), mockChecker{child.Wrap()})
+	var fe *checker.FieldErrors
+	assert.False(t, errors.As(err, &fe))
+	var codeErr errs.CodeError
+	if assert.True(t, errors.As(err, &codeErr)) {
+		assert.Equal(t, 31001, codeErr.Code())
+		assert.Equal(t, "user u1", codeErr.Detail())
+	}
+}
+
+type tagTreeNode struct {
+	Name     string         `json:"name"`
+	Children []*tagTreeNode `json:"children"`
+	Leaf     *tagLeaf       `json:"leaf"`
+}
+
+type tagLeaf struct {
+	Value string `json:"value" check:"required"`
+}
+
+type tagPlain struct {
+	Rows  [][]string     `json:"rows"`
+	Attrs map[string]int `json:"attrs"`
+	Any   any            `json:"any"`
+}
+
+func TestValidateTagsSkipsSubtreesWithoutRules(t *testing.T) {
+	// The rule is only reachable through the recursive Children field.
+	tree := &tagTreeNode{Children: []*tagTreeNode{{Children: []*tagTreeNode{{Leaf: &tagLeaf{}}}}}}
+	err := checker.Validate(context.Background(), tree)
+	assert.ErrorIs(t, err, errs.ErrArgs)
+	assert.Equal(t, "children[0].children[0].leaf.value is required", errs.Unwrap(err).(errs.CodeError).Detail())
+
+	plain := &tagPlain{Rows: [][]string{{"a", "b"}}, Attrs: map[string]int{"a": 1}}
+	assert.NoError(t, checker.Validate(context.Background(), plain))
+
+	// An interface may hold a type with rules and is always walked.
+	plain.Any = &tagLeaf{}
+	err = checker.Validate(context.Background(), plain)
+	assert.Equal(t, "any.value is required", errs.Unwrap(err).(errs.CodeError).Detail())
+}
@@ -0,0 +1,65 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command confencrypt encrypts configuration values into "enc:" values resolved by
+// config.ResolveSecrets. The key is read from CONFIG_SECRET_KEY or CONFIG_SECRET_KEY_FILE, the
+// values from the arguments or, one per line, from the standard input:
+//
+//	CONFIG_SECRET_KEY_FILE=/run/secrets/config-key confencrypt openIM123
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Meikwei/go-tools/config"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: confencrypt [value ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := run(flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(values []string) error {
+	key, err := config.SecretKey()
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			values = append(values, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	for _, value := range values {
+		enc, err := config.EncryptValue(value, key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(enc)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command zkconf publishes a local configuration file to the ZooKeeper registry, where services
+// read it through config.RegistrySource:
+//
+//	zkconf -zk 127.0.0.1:2181 -scheme openim -key mongodb.yml -file config/mongodb.yml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Meikwei/go-tools/config"
+	"github.com/Meikwei/go-tools/discovery/zookeeper"
+	"github.com/Meikwei/go-tools/errs"
+)
+
+func main() {
+	var (
+		servers  = flag.String("zk", "127.0.0.1:2181", "comma separated ZooKeeper addresses")
+		scheme   = flag.String("scheme", "openim", "registry scheme, the root node of the keys")
+		username = flag.String("username", "", "ZooKeeper username")
+		password = flag.String("password", "", "ZooKeeper password")
+		key      = flag.String("key", "", "registry key, the file name by default")
+		file     = flag.String("file", "", "configuration file to publish")
+	)
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *key == "" {
+		*key = filepath.Base(*file)
+	}
+	if err := publish(strings.Split(*servers, ","), *scheme, *username, *password, *key, *file); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func publish(servers []string, scheme, username, password, key, file string) error {
+	client, err := zookeeper.NewZkClient(servers, scheme, zookeeper.WithUserNameAndPassword(username, password))
+	if err != nil {
+		return errs.WrapMsg(err, "connect zookeeper failed", "servers", servers)
+	}
+	defer client.Close()
+	if err := config.PublishFile(client, key, file); err != nil {
+		return errs.WrapMsg(err, "publish config failed", "key", key, "file", file)
+	}
+	fmt.Printf("published %s to %s/%s\n", file, client.GetRootPath(), key)
+	return nil
+}
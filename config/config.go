@@ -19,38 +19,91 @@ import (
 	"path/filepath"
 
 	"github.com/Meikwei/go-tools/errs"
-	"gopkg.in/yaml.v2"
 )
 
-// Loader is responsible for loading configuration files.
+// Loader is responsible for loading configuration files. When its PathResolver is a
+// ProfileResolver selecting a profile, the profile overlay of the file, see ProfileFile, is merged
+// over it when it exists.
 type Loader struct {
 	PathResolver PathResolver
+	// EnvOverlay makes InitConfig apply ApplyEnv with EnvPrefix after the file is read.
+	EnvOverlay bool
+	EnvPrefix  string
 }
 
+// NewLoader creates a Loader, a nil pathResolver uses NewPathResolver.
 func NewLoader(pathResolver PathResolver) *Loader {
+	if pathResolver == nil {
+		pathResolver = NewPathResolver()
+	}
 	return &Loader{PathResolver: pathResolver}
 }
 
 func (c *Loader) InitConfig(config any, configName, configFolderPath string) error {
+	_, err := c.InitConfigWithProvenance(config, configName, configFolderPath)
+	return err
+}
+
+// InitConfigWithProvenance loads config like InitConfig and returns the origin of its values, see Dump.
+func (c *Loader) InitConfigWithProvenance(config any, configName, configFolderPath string) (Provenance, error) {
 	configFolderPath, err := c.resolveConfigPath(configName, configFolderPath)
 	if err != nil {
-		return errs.WrapMsg(err, "resolveConfigPath failed", "configName", configName, "configFolderPath", configFolderPath)
+		return nil, errs.WrapMsg(err, "resolveConfigPath failed", "configName", configName, "configFolderPath", configFolderPath)
 	}
 
 	data, err := os.ReadFile(configFolderPath)
 	if err != nil {
-		return errs.WrapMsg(err, "ReadFile failed", "configFolderPath", configFolderPath)
+		return nil, errs.WrapMsg(err, "ReadFile failed", "configFolderPath", configFolderPath)
+	}
+
+	prov := make(Provenance)
+	parser := ParserForFile(configFolderPath, data)
+	overlay := c.profileOverlay(configFolderPath)
+	if overlay == "" {
+		if err = parser.Parse(data, config); err != nil {
+			return nil, errs.WrapMsg(err, "failed to unmarshal config data", "configName", configName)
+		}
+		if tree, err := parseTree(parser, data); err == nil {
+			prov.setTree(tree, "", Origin{Kind: OriginFile, Name: configFolderPath})
+		}
+	} else {
+		tree, err := parseTree(parser, data)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "failed to unmarshal config data", "configName", configName)
+		}
+		overlayData, err := os.ReadFile(overlay)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "ReadFile failed", "overlay", overlay)
+		}
+		overlayTree, err := parseTree(ParserForFile(overlay, overlayData), overlayData)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "failed to unmarshal config data", "overlay", overlay)
+		}
+		tree.Merge(overlayTree)
+		prov.setTree(tree, "", Origin{Kind: OriginFile, Name: configFolderPath})
+		prov.setTree(overlayTree, "", Origin{Kind: OriginFile, Name: overlay})
+		if err := tree.Decode(config); err != nil {
+			return nil, errs.WrapMsg(err, "decode config failed", "configName", configName, "overlay", overlay)
+		}
 	}
 
-	if err = yaml.Unmarshal(data, config); err != nil {
-		return errs.WrapMsg(err, "failed to unmarshal config data", "configName", configName)
+	if c.EnvOverlay {
+		if err := applyEnv(config, c.EnvPrefix, prov); err != nil {
+			return nil, errs.WrapMsg(err, "ApplyEnv failed", "configName", configName)
+		}
 	}
 
-	return nil
+	if err := ResolveSecrets(config); err != nil {
+		return nil, errs.WrapMsg(err, "ResolveSecrets failed", "configName", configName)
+	}
+	return prov, nil
 }
 
 func (c *Loader) resolveConfigPath(configName, configFolderPath string) (string, error) {
 	if configFolderPath == "" {
+		if finder, ok := c.PathResolver.(ConfigFileFinder); ok {
+			return finder.FindConfigFile(configName)
+		}
 		var err error
 		configFolderPath, err = c.PathResolver.GetDefaultConfigPath()
 		if err != nil {
@@ -69,3 +122,25 @@ func (c *Loader) resolveConfigPath(configName, configFolderPath string) (string,
 	}
 	return configFilePath, nil
 }
+
+// profileFile returns the overlay of the file at path for the selected profile, empty without a
+// profile.
+func (c *Loader) profileFile(path string) string {
+	resolver, ok := c.PathResolver.(ProfileResolver)
+	if !ok || resolver.Profile() == "" {
+		return ""
+	}
+	return ProfileFile(path, resolver.Profile())
+}
+
+// profileOverlay returns the profile overlay of the file at path when it exists.
+func (c *Loader) profileOverlay(path string) string {
+	overlay := c.profileFile(path)
+	if overlay == "" {
+		return ""
+	}
+	if _, err := os.Stat(overlay); err != nil {
+		return ""
+	}
+	return overlay
+}
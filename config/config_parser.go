@@ -14,7 +14,14 @@
 
 package config
 
-import "gopkg.in/yaml.v2"
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
 
 // Parser Configures the parser interface.
 type Parser interface {
@@ -27,3 +34,59 @@ type YAMLParser struct{}
 func (y *YAMLParser) Parse(data []byte, out any) error {
 	return yaml.Unmarshal(data, out)
 }
+
+// JSONParser Configuration parser in JSON format. Like the other parsers, it fills structs through
+// their yaml tags so that a configuration can mix formats across layers.
+type JSONParser struct{}
+
+func (j *JSONParser) Parse(data []byte, out any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree map[string]any
+	if err := dec.Decode(&tree); err != nil {
+		return errs.WrapMsg(err, "json decode failed")
+	}
+	return decodeTree(jsonNumbers(tree).(map[string]any), out)
+}
+
+// jsonNumbers converts the json.Number values to int64 or float64.
+func jsonNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			val[k] = jsonNumbers(child)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = jsonNumbers(child)
+		}
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	}
+	return v
+}
+
+// TOMLParser Configuration parser in TOML format, structs are filled through their yaml tags.
+type TOMLParser struct{}
+
+func (t *TOMLParser) Parse(data []byte, out any) error {
+	var tree map[string]any
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return errs.WrapMsg(err, "toml decode failed")
+	}
+	return decodeTree(tree, out)
+}
+
+// decodeTree stores a parsed document into out, a *map[string]any or a value decoded through its
+// yaml tags.
+func decodeTree(tree map[string]any, out any) error {
+	if m, ok := out.(*map[string]any); ok {
+		*m = tree
+		return nil
+	}
+	return normalize(tree).(Tree).Decode(out)
+}
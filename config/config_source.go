@@ -16,8 +16,10 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	"github.com/Meikwei/go-tools/errs"
+	"gopkg.in/yaml.v2"
 )
 
 // ConfigSource configuring source interfaces.
@@ -25,6 +27,11 @@ type ConfigSource interface {
 	Read() ([]byte, error)
 }
 
+// ParserDetector is implemented by sources that pick the parser of their content.
+type ParserDetector interface {
+	DetectParser(data []byte) Parser
+}
+
 // EnvVarSource read a configuration from an environment variable.
 type EnvVarSource struct {
 	VarName string
@@ -47,3 +54,33 @@ func (f *FileSystemSource) Read() ([]byte, error) {
 	r, err := os.ReadFile(f.FilePath)
 	return r, errs.WrapMsg(err, "ReadFile failed ", "FilePath", f.FilePath)
 }
+
+// DetectParser picks the parser from the file extension or the content, see ParserForFile.
+func (f *FileSystemSource) DetectParser(data []byte) Parser {
+	return ParserForFile(f.FilePath, data)
+}
+
+// MapSource provides values keyed by dotted yaml paths, such as "mongo.maxPoolSize", for example
+// the defaults of an application or its command line flags. It is read as a YAML document.
+type MapSource struct {
+	Values map[string]any
+}
+
+func (m *MapSource) Read() ([]byte, error) {
+	tree := make(Tree)
+	for key, value := range m.Values {
+		path := strings.Split(key, ".")
+		node := tree
+		for _, name := range path[:len(path)-1] {
+			child, ok := node[name].(Tree)
+			if !ok {
+				child = make(Tree)
+				node[name] = child
+			}
+			node = child
+		}
+		node[path[len(path)-1]] = value
+	}
+	data, err := yaml.Marshal(tree)
+	return data, errs.WrapMsg(err, "marshal map source failed")
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDump struct {
+	Mongo struct {
+		URI      string   `yaml:"uri"`
+		Address  []string `yaml:"address"`
+		Database string   `yaml:"database"`
+		Password string   `yaml:"password"`
+	} `yaml:"mongo"`
+	Object struct {
+		SecretAccessKey string `yaml:"secretAccessKey"`
+		Token           string `yaml:"token" secret:"true"`
+		Bucket          string `yaml:"bucket"`
+	} `yaml:"object"`
+	Kafka struct {
+		ClientKeyPwd string `yaml:"clientKeyPwd"`
+	} `yaml:"kafka"`
+	Port int `yaml:"port"`
+}
+
+func TestLoadWithProvenance(t *testing.T) {
+	file := writeFile(t, "app.yml", "mongo:\n  address: [a:27017]\n  database: openim\n  password: secret\nobject:\n  bucket: im\n")
+	t.Setenv("TEST_MONGO_ADDRESS_1", "b:27017")
+	t.Setenv("TEST_OBJECT_TOKEN", "tok")
+
+	m := NewManager(nil)
+	m.AddSource(&MapSource{Values: map[string]any{"port": 10001, "mongo.database": "default"}}, WithPrecedence(PrecedenceDefaults))
+	m.AddSource(&FileSystemSource{FilePath: file})
+	m.AddSource(&MapSource{Values: map[string]any{"object.bucket": "flag"}}, WithPrecedence(PrecedenceFlags))
+	m.SetEnvOverlay("TEST")
+	var conf testDump
+	prov, err := m.LoadWithProvenance(&conf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "default", prov.Lookup("port").String())
+	assert.Equal(t, "file:"+file, prov.Lookup("mongo.database").String())
+	assert.Equal(t, "file:"+file, prov.Lookup("mongo.address[0]").String())
+	assert.Equal(t, "env:TEST_MONGO_ADDRESS_1", prov.Lookup("mongo.address[1]").String())
+	assert.Equal(t, "env:TEST_OBJECT_TOKEN", prov.Lookup("object.token").String())
+	assert.Equal(t, "flag", prov.Lookup("object.bucket").String())
+	assert.Equal(t, "default", prov.Lookup("kafka.clientKeyPwd").String())
+
+	data, err := Dump(&conf, prov, DumpYAML)
+	assert.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, "database: openim # file:"+file)
+	assert.Contains(t, out, "- b:27017 # env:TEST_MONGO_ADDRESS_1")
+	assert.Contains(t, out, "password: '******' # file:"+file)
+	assert.Contains(t, out, "token: '******' # env:TEST_OBJECT_TOKEN")
+	assert.Contains(t, out, `clientKeyPwd: "" # default`)
+	assert.NotContains(t, out, "secret\n")
+	assert.NotContains(t, out, "tok ")
+
+	data, err = Dump(&conf, prov, DumpJSON)
+	assert.NoError(t, err)
+	var dump struct {
+		Config  map[string]any    `json:"config"`
+		Origins map[string]string `json:"origins"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &dump))
+	assert.Equal(t, "******", dump.Config["mongo"].(map[string]any)["password"])
+	assert.Equal(t, "flag", dump.Config["object"].(map[string]any)["bucket"])
+	assert.Equal(t, "flag", dump.Origins["object.bucket"])
+	assert.Equal(t, "env:TEST_MONGO_ADDRESS_1", dump.Origins["mongo.address[1]"])
+
+	_, err = Dump(&conf, prov, "xml")
+	assert.Error(t, err)
+}
+
+func TestDumpMasksURLUserinfo(t *testing.T) {
+	var conf testDump
+	conf.Mongo.URI = "mongodb://root:pass@a:27017,b:27017/openim?authSource=admin"
+	conf.Mongo.Address = []string{"redis://:pass@a:6379/0", "http://a/b@c", "a:27017"}
+	data, err := Dump(&conf, nil, DumpYAML)
+	assert.NoError(t, err)
+	out := string(data)
+	assert.NotContains(t, out, "pass@")
+	assert.Contains(t, out, "uri: mongodb://******@a:27017,b:27017/openim?authSource=admin")
+	assert.Contains(t, out, "- redis://******@a:6379/0")
+	assert.Contains(t, out, "- http://a/b@c")
+	assert.Contains(t, out, "- a:27017")
+}
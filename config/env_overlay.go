@@ -0,0 +1,303 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// ApplyEnv overrides the fields of config with environment variables named after their yaml
+// path: the prefix and the yaml names, upper-cased and joined with "_". With the prefix "KAFKA",
+// the field tls.enableTLS is read from KAFKA_TLS_ENABLETLS.
+//
+// Slice elements are addressed by index, MONGO_ADDRESS_0, and a whole slice of scalars can be set
+// as a comma separated list, MONGO_ADDRESS=a:27017,b:27017. Map values are addressed by their
+// upper-cased key when the key exists, and a whole map of scalars can be set as k1=v1,k2=v2.
+// Durations use time.ParseDuration and types implementing encoding.TextUnmarshaler are supported.
+func ApplyEnv(config any, prefix string) error {
+	return applyEnv(config, prefix, nil)
+}
+
+// applyEnv applies the environment variables and records their names in prov.
+func applyEnv(config any, prefix string, prov Provenance) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errs.New("config must be a non-nil pointer").Wrap()
+	}
+	o := &envOverlay{vars: environ(), prov: prov}
+	return o.apply(v.Elem(), strings.ToUpper(prefix), "")
+}
+
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+type envOverlay struct {
+	vars map[string]string
+	prov Provenance
+}
+
+func (o *envOverlay) lookup(name string) (string, bool) {
+	v, ok := o.vars[name]
+	return v, ok
+}
+
+// hasPrefix reports whether a variable is set under name, itself or a nested path of it.
+func (o *envOverlay) hasPrefix(name string) bool {
+	if _, ok := o.vars[name]; ok {
+		return true
+	}
+	for k := range o.vars {
+		if strings.HasPrefix(k, name+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(prefix, name string) string {
+	name = strings.ToUpper(name)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// apply sets v from the variable name and the variables nested under it, path is the yaml path
+// of v.
+func (o *envOverlay) apply(v reflect.Value, name, path string) error {
+	if isScalar(v.Type()) {
+		raw, ok := o.lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setScalar(v, raw, name); err != nil {
+			return err
+		}
+		o.prov.set(path, Origin{Kind: OriginEnv, Name: name})
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !o.hasPrefix(name) {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return o.apply(v.Elem(), name, path)
+	case reflect.Struct:
+		return o.applyStruct(v, name, path)
+	case reflect.Slice:
+		return o.applySlice(v, name, path)
+	case reflect.Map:
+		return o.applyMap(v, name, path)
+	}
+	return nil
+}
+
+func (o *envOverlay) applyStruct(v reflect.Value, name, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tagName, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tagName == "-" {
+			continue
+		}
+		fieldName, fieldPath := name, path
+		if !strings.Contains(opts, "inline") {
+			if tagName == "" {
+				tagName = strings.ToLower(field.Name)
+			}
+			fieldName, fieldPath = envName(name, tagName), joinPath(path, tagName)
+		}
+		if err := o.apply(v.Field(i), fieldName, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *envOverlay) applySlice(v reflect.Value, name, path string) error {
+	elem := v.Type().Elem()
+	if raw, ok := o.lookup(name); ok && isScalar(elem) {
+		parts := splitList(raw)
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setScalar(s.Index(i), part, name); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		o.prov.set(path, Origin{Kind: OriginEnv, Name: name})
+	}
+	for i := 0; ; i++ {
+		elemName := envName(name, strconv.Itoa(i))
+		if i >= v.Len() {
+			if !o.hasPrefix(elemName) {
+				return nil
+			}
+			v.Set(reflect.Append(v, reflect.Zero(elem)))
+		}
+		if err := o.apply(v.Index(i), elemName, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *envOverlay) applyMap(v reflect.Value, name, path string) error {
+	t := v.Type()
+	if t.Key().Kind() != reflect.String {
+		return nil
+	}
+	if raw, ok := o.lookup(name); ok && isScalar(t.Elem()) {
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for _, part := range splitList(raw) {
+			key, value, ok := strings.Cut(part, "=")
+			if !ok {
+				return errs.New("invalid map entry in environment variable", "name", name, "entry", part).Wrap()
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := setScalar(elem, value, name); err != nil {
+				return err
+			}
+			key = strings.TrimSpace(key)
+			v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+			o.prov.set(joinPath(path, key), Origin{Kind: OriginEnv, Name: name})
+		}
+	}
+	for _, key := range v.MapKeys() {
+		elem := reflect.New(t.Elem()).Elem()
+		elem.Set(v.MapIndex(key))
+		if err := o.apply(elem, envName(name, key.String()), joinPath(path, key.String())); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// isScalar reports whether values of t are set from a single environment variable.
+func isScalar(t reflect.Type) bool {
+	if t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Ptr:
+		return t.Elem().Kind() != reflect.Struct && isScalar(t.Elem())
+	default:
+		return false
+	}
+}
+
+func setScalar(v reflect.Value, raw, name string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if !v.Type().Implements(textUnmarshalerType) {
+			return setScalar(v.Elem(), raw, name)
+		}
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return errs.WrapMsg(err, "parse environment variable failed", "name", name)
+		}
+		return nil
+	}
+	if v.Type().Implements(textUnmarshalerType) {
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return errs.WrapMsg(err, "parse environment variable failed", "name", name)
+		}
+		return nil
+	}
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(raw); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if v.Type() == durationType {
+			var d time.Duration
+			if d, err = time.ParseDuration(raw); err == nil {
+				n = int64(d)
+			}
+		} else {
+			n, err = strconv.ParseInt(raw, 10, v.Type().Bits())
+		}
+		if err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(raw, 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(raw, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	}
+	if err != nil {
+		return errs.WrapMsg(err, "parse environment variable failed", "name", name)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTLS struct {
+	EnableTLS bool `yaml:"enableTLS"`
+}
+
+type testKafka struct {
+	Addr     []string          `yaml:"addr"`
+	TLS      testTLS           `yaml:"tls"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Topics   map[string]string `yaml:"topics"`
+	Retry    *int              `yaml:"retry"`
+	Brokers  []testTLS         `yaml:"brokers"`
+	Internal string            `yaml:"-"`
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("KAFKA_ADDR_1", "b:9092")
+	t.Setenv("KAFKA_ADDR_2", "c:9092")
+	t.Setenv("KAFKA_TLS_ENABLETLS", "true")
+	t.Setenv("KAFKA_TIMEOUT", "3s")
+	t.Setenv("KAFKA_TOPICS_MSG", "msg2")
+	t.Setenv("KAFKA_RETRY", "5")
+	t.Setenv("KAFKA_BROKERS_0_ENABLETLS", "true")
+	t.Setenv("KAFKA_INTERNAL", "ignored")
+
+	conf := testKafka{Addr: []string{"a:9092", "b"}, Topics: map[string]string{"msg": "msg1"}}
+	assert.NoError(t, ApplyEnv(&conf, "kafka"))
+	retry := 5
+	assert.Equal(t, testKafka{
+		Addr:    []string{"a:9092", "b:9092", "c:9092"},
+		TLS:     testTLS{EnableTLS: true},
+		Timeout: 3 * time.Second,
+		Topics:  map[string]string{"msg": "msg2"},
+		Retry:   &retry,
+		Brokers: []testTLS{{EnableTLS: true}},
+	}, conf)
+
+	t.Setenv("KAFKA_ADDR", "x:1, y:2")
+	t.Setenv("KAFKA_TOPICS", "a=1,b=2")
+	conf = testKafka{}
+	assert.NoError(t, ApplyEnv(&conf, "KAFKA"))
+	assert.Equal(t, []string{"x:1", "b:9092", "c:9092"}, conf.Addr)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, conf.Topics)
+
+	t.Setenv("KAFKA_TIMEOUT", "soon")
+	assert.ErrorContains(t, ApplyEnv(&conf, "KAFKA"), "KAFKA_TIMEOUT")
+}
+
+func TestManagerEnvOverlay(t *testing.T) {
+	t.Setenv("MONGO_DATABASE", "env")
+	t.Setenv("MONGO_ADDRESS_0", "c:27017")
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: writeFile(t, "mongo.yml", "address: [a:27017, b:27017]\ndatabase: openim\n")})
+	m.SetEnvOverlay("MONGO")
+	var conf testMongo
+	assert.NoError(t, m.Load(&conf))
+	assert.Equal(t, testMongo{Address: []string{"c:27017", "b:27017"}, Database: "env"}, conf)
+}
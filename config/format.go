@@ -0,0 +1,69 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ParserForFile returns the parser of a configuration file from its extension: .json, .toml,
+// .yaml and .yml. Other files are recognized by their content, see DetectParser.
+func ParserForFile(path string, data []byte) Parser {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return &JSONParser{}
+	case ".toml":
+		return &TOMLParser{}
+	case ".yaml", ".yml":
+		return &YAMLParser{}
+	default:
+		return DetectParser(data)
+	}
+}
+
+var (
+	tomlTable = regexp.MustCompile(`^\[\[?\s*[A-Za-z0-9_\-."' ]+\]\]?\s*(#.*)?$`)
+	tomlKey   = regexp.MustCompile(`^[A-Za-z0-9_\-."']+\s*=`)
+)
+
+// DetectParser guesses the format of a configuration from its first significant line: a JSON
+// object, a TOML table header or key = value pair, and YAML otherwise. A document starting with
+// "{" that is not valid JSON is parsed as a YAML flow mapping.
+func DetectParser(data []byte) Parser {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "{"):
+			if json.Valid(data) {
+				return &JSONParser{}
+			}
+			return &YAMLParser{}
+		case tomlTable.MatchString(line), tomlKey.MatchString(line):
+			return &TOMLParser{}
+		default:
+			return &YAMLParser{}
+		}
+	}
+	return &YAMLParser{}
+}
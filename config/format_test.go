@@ -0,0 +1,66 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectParser(t *testing.T) {
+	tests := map[string]Parser{
+		"{\"a\": 1}":                  &JSONParser{},
+		"# comment\n{\"a\": 1}":       &YAMLParser{},
+		"{a: 1, b: [x]}":              &YAMLParser{},
+		"[mongo]\naddress = ['a']":    &TOMLParser{},
+		"# comment\n\ndebug = true\n": &TOMLParser{},
+		"mongo:\n  address: [a]\n":    &YAMLParser{},
+		"- a\n- b\n":                  &YAMLParser{},
+		"":                            &YAMLParser{},
+		"[[servers]]\nname = \"a\"\n": &TOMLParser{},
+	}
+	for data, want := range tests {
+		assert.IsType(t, want, DetectParser([]byte(data)), data)
+	}
+	assert.IsType(t, &JSONParser{}, ParserForFile("app.JSON", []byte("a: 1")))
+	assert.IsType(t, &YAMLParser{}, ParserForFile("app.yml", []byte("{}")))
+}
+
+func TestParsers(t *testing.T) {
+	want := testConfig{Mongo: testMongo{Address: []string{"a:27017"}, Database: "openim", MaxPoolSize: 100}, Debug: true}
+
+	var conf testConfig
+	assert.NoError(t, (&JSONParser{}).Parse([]byte(`{"mongo":{"address":["a:27017"],"database":"openim","maxPoolSize":100},"debug":true}`), &conf))
+	assert.Equal(t, want, conf)
+
+	conf = testConfig{}
+	assert.NoError(t, (&TOMLParser{}).Parse([]byte("debug = true\n[mongo]\naddress = ['a:27017']\ndatabase = 'openim'\nmaxPoolSize = 100\n"), &conf))
+	assert.Equal(t, want, conf)
+
+	assert.Error(t, (&JSONParser{}).Parse([]byte(`{`), &conf))
+	assert.Error(t, (&TOMLParser{}).Parse([]byte(`a = `), &conf))
+}
+
+func TestLayerParser(t *testing.T) {
+	source := &FileSystemSource{FilePath: "app.json"}
+	data := []byte("a = 1\n")
+	assert.IsType(t, &JSONParser{}, NewManager(nil).layerParser(&layer{source: source}, data))
+	assert.IsType(t, &YAMLParser{}, NewManager(&YAMLParser{}).layerParser(&layer{source: source}, data))
+	assert.IsType(t, &TOMLParser{}, NewManager(&YAMLParser{}).layerParser(&layer{source: source, parser: &TOMLParser{}}, data))
+	assert.IsType(t, &TOMLParser{}, NewManager(nil).layerParser(&layer{source: &EnvVarSource{}}, data))
+}
+
+func TestMixedFormats(t *testing.T) {
+	m := NewManager(nil)
+	m.AddSource(&FileSystemSource{FilePath: writeFile(t, "mongo.yml", "mongo:\n  address: [a:27017]\n  database: openim\n")})
+	m.AddSource(&FileSystemSource{FilePath: writeFile(t, "mongo.json", `{"mongo": {"maxPoolSize": 100}}`)})
+	m.AddSource(&FileSystemSource{FilePath: writeFile(t, "override.conf", "debug = true\n")})
+	var conf testConfig
+	assert.NoError(t, m.Load(&conf))
+	assert.Equal(t, testConfig{Mongo: testMongo{Address: []string{"a:27017"}, Database: "openim", MaxPoolSize: 100}, Debug: true}, conf)
+
+	file := writeFile(t, "mongo.json", `{"address": ["b:27017"], "database": "im"}`)
+	var mongo testMongo
+	assert.NoError(t, NewLoader(nil).InitConfig(&mongo, filepath.Base(file), filepath.Dir(file)))
+	assert.Equal(t, testMongo{Address: []string{"b:27017"}, Database: "im"}, mongo)
+}
@@ -14,9 +14,65 @@
 
 package config
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// Precedence orders the layers of a Manager, a source of higher precedence overrides the values
+// of the sources below it.
+type Precedence int
+
+const (
+	PrecedenceDefaults Precedence = iota
+	PrecedenceFile
+	PrecedenceEnv
+	PrecedenceFlags
+)
+
+// SourceOption configures a source added to a Manager.
+type SourceOption func(*layer)
+
+// WithPrecedence sets the precedence of the source. EnvVarSource defaults to PrecedenceEnv, other
+// sources to PrecedenceFile.
+func WithPrecedence(precedence Precedence) SourceOption {
+	return func(l *layer) {
+		l.precedence = precedence
+	}
+}
+
+// Optional skips the source when it cannot be read. Its parse errors are still returned.
+func Optional() SourceOption {
+	return func(l *layer) {
+		l.optional = true
+	}
+}
+
+// WithParser parses the source with parser instead of the detected one or the parser of the Manager.
+func WithParser(parser Parser) SourceOption {
+	return func(l *layer) {
+		l.parser = parser
+	}
+}
+
+type layer struct {
+	source     ConfigSource
+	parser     Parser
+	precedence Precedence
+	optional   bool
+}
+
+// Manager loads a configuration from layered sources. Each source is parsed into a Tree, the
+// trees are deep-merged by precedence, sources of equal precedence in the order they were added,
+// and the result is decoded into the configuration. Secret references are then resolved, see
+// ResolveSecrets.
 type Manager struct {
-	sources []ConfigSource
-	parser  Parser
+	sources    []*layer
+	parser     Parser
+	envOverlay bool
+	envPrefix  string
 }
 
 func NewManager(parser Parser) *Manager {
@@ -25,18 +81,103 @@ func NewManager(parser Parser) *Manager {
 	}
 }
 
-func (cm *Manager) AddSource(source ConfigSource) {
-	cm.sources = append(cm.sources, source)
+func (cm *Manager) AddSource(source ConfigSource, opts ...SourceOption) {
+	l := &layer{source: source, precedence: PrecedenceFile}
+	if _, ok := source.(*EnvVarSource); ok {
+		l.precedence = PrecedenceEnv
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	cm.sources = append(cm.sources, l)
 }
 
-func (cm *Manager) Load(config any) error {
-	for _, source := range cm.sources {
-		if data, err := source.Read(); err == nil {
-			if err := cm.parser.Parse(data, config); err != nil {
-				return err
+// SetEnvOverlay makes Load apply ApplyEnv with prefix on top of the merged sources.
+func (cm *Manager) SetEnvOverlay(prefix string) {
+	cm.envOverlay = true
+	cm.envPrefix = prefix
+}
+
+// Tree reads, parses and merges the sources.
+func (cm *Manager) Tree() (Tree, error) {
+	return cm.tree(nil)
+}
+
+// tree merges the sources and records the origin of their values in prov.
+func (cm *Manager) tree(prov Provenance) (Tree, error) {
+	layers := make([]*layer, len(cm.sources))
+	copy(layers, cm.sources)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].precedence < layers[j].precedence })
+	tree := make(Tree)
+	for _, l := range layers {
+		data, err := l.source.Read()
+		if err != nil {
+			if l.optional {
+				continue
 			}
-			break
+			return nil, errs.WrapMsg(err, "read config source failed", "source", sourceName(l.source))
+		}
+		parser := cm.layerParser(l, data)
+		t, err := parseTree(parser, data)
+		if err != nil {
+			return nil, errs.WrapMsg(err, "parse config source failed", "source", sourceName(l.source))
 		}
+		tree.Merge(t)
+		prov.setTree(t, "", layerOrigin(l))
+	}
+	return tree, nil
+}
+
+func (cm *Manager) Load(config any) error {
+	_, err := cm.LoadWithProvenance(config)
+	return err
+}
+
+// LoadWithProvenance loads config like Load and returns the origin of its values, see Dump.
+func (cm *Manager) LoadWithProvenance(config any) (Provenance, error) {
+	prov := make(Provenance)
+	tree, err := cm.tree(prov)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.Decode(config); err != nil {
+		return nil, err
+	}
+	if cm.envOverlay {
+		if err := applyEnv(config, cm.envPrefix, prov); err != nil {
+			return nil, err
+		}
+	}
+	if err := ResolveSecrets(config); err != nil {
+		return nil, err
+	}
+	return prov, nil
+}
+
+// layerParser returns the parser of a layer: its own, then the parser of the Manager, then the
+// one detected by its source, and the one detected from the data otherwise.
+func (cm *Manager) layerParser(l *layer, data []byte) Parser {
+	if l.parser != nil {
+		return l.parser
+	}
+	if cm.parser != nil {
+		return cm.parser
+	}
+	if detector, ok := l.source.(ParserDetector); ok {
+		return detector.DetectParser(data)
+	}
+	return DetectParser(data)
+}
+
+func sourceName(source ConfigSource) string {
+	switch s := source.(type) {
+	case *FileSystemSource:
+		return s.FilePath
+	case *EnvVarSource:
+		return "env:" + s.VarName
+	case *RegistrySource:
+		return "registry:" + s.Key
+	default:
+		return fmt.Sprintf("%T", source)
 	}
-	return nil
 }
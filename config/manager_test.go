@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMongo struct {
+	Address     []string `yaml:"address"`
+	Database    string   `yaml:"database"`
+	MaxPoolSize int      `yaml:"maxPoolSize"`
+}
+
+type testConfig struct {
+	Mongo testMongo `yaml:"mongo"`
+	Debug bool      `yaml:"debug"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestManagerLayers(t *testing.T) {
+	file := writeFile(t, "mongo.yml", "mongo:\n  address: [a:27017, b:27017]\n  database: openim\n")
+	t.Setenv("TEST_CONFIG_YAML", "mongo:\n  database: prod\n")
+
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&MapSource{Values: map[string]any{"debug": true}}, WithPrecedence(PrecedenceFlags))
+	m.AddSource(&EnvVarSource{VarName: "TEST_CONFIG_YAML"})
+	m.AddSource(&FileSystemSource{FilePath: file})
+	m.AddSource(&MapSource{Values: map[string]any{"mongo.maxPoolSize": 100, "mongo.database": "default"}}, WithPrecedence(PrecedenceDefaults))
+	m.AddSource(&EnvVarSource{VarName: "TEST_CONFIG_UNSET"}, Optional())
+
+	var conf testConfig
+	assert.NoError(t, m.Load(&conf))
+	assert.Equal(t, testConfig{
+		Mongo: testMongo{Address: []string{"a:27017", "b:27017"}, Database: "prod", MaxPoolSize: 100},
+		Debug: true,
+	}, conf)
+}
+
+func TestManagerErrors(t *testing.T) {
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: filepath.Join(t.TempDir(), "missing.yml")})
+	assert.ErrorContains(t, m.Load(&testConfig{}), "read config source failed")
+
+	m = NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: writeFile(t, "bad.yml", "mongo: [")}, Optional())
+	assert.ErrorContains(t, m.Load(&testConfig{}), "parse config source failed")
+}
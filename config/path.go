@@ -17,6 +17,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Meikwei/go-tools/errs"
 )
@@ -27,36 +28,129 @@ type PathResolver interface {
 	GetProjectRoot() (string, error)
 }
 
-type defaultPathResolver struct{}
+// ConfigFileFinder is implemented by resolvers searching several directories for a configuration
+// file. Loader uses it when no folder is given.
+type ConfigFileFinder interface {
+	FindConfigFile(configName string) (string, error)
+}
 
-// NewPathResolver creates a new instance of the default path resolver.
-func NewPathResolver() *defaultPathResolver {
-	return &defaultPathResolver{}
+// ProfileResolver is implemented by resolvers selecting a profile, its overlay file is merged over
+// the base file by Loader, see ProfileFile.
+type ProfileResolver interface {
+	Profile() string
 }
 
-func (d *defaultPathResolver) GetDefaultConfigPath(relativePath string) (string, error) {
-	executablePath, err := os.Executable()
-	if err != nil {
-		return "", errs.WrapMsg(err, "Executable failed")
+const (
+	// ConfigDirEnv names the configuration directory searched after the explicit one.
+	ConfigDirEnv = "APP_CONFIG_DIR"
+	// ProfileEnv selects the profile overlays, "prod" merges mongodb.prod.yml over mongodb.yml.
+	ProfileEnv = "APP_PROFILE"
+)
+
+// PathOption configures the default path resolver.
+type PathOption func(*defaultPathResolver)
+
+// WithConfigDir sets the directory searched first, usually given by a command line flag.
+func WithConfigDir(dir string) PathOption {
+	return func(d *defaultPathResolver) {
+		d.configDir = dir
+	}
+}
+
+// WithAppName searches /etc/<app> last.
+func WithAppName(app string) PathOption {
+	return func(d *defaultPathResolver) {
+		d.app = app
+	}
+}
+
+// WithProfileEnv reads the profile from the environment variable name instead of ProfileEnv.
+func WithProfileEnv(name string) PathOption {
+	return func(d *defaultPathResolver) {
+		d.profileEnv = name
+	}
+}
+
+var _ PathResolver = (*defaultPathResolver)(nil)
+
+type defaultPathResolver struct {
+	configDir  string
+	app        string
+	profileEnv string
+}
+
+// NewPathResolver creates a new instance of the default path resolver. It searches the
+// configuration files in order in the directory of WithConfigDir, $APP_CONFIG_DIR, the directory
+// of the executable, config/ under the working directory and /etc/<app> with WithAppName.
+func NewPathResolver(opts ...PathOption) *defaultPathResolver {
+	d := &defaultPathResolver{profileEnv: ProfileEnv}
+	for _, opt := range opts {
+		opt(d)
 	}
+	return d
+}
 
-	configPath := filepath.Join(filepath.Dir(executablePath), relativePath)
-	return configPath, nil
+// SearchDirs returns the directories searched, in order.
+func (d *defaultPathResolver) SearchDirs() []string {
+	var dirs []string
+	if d.configDir != "" {
+		dirs = append(dirs, d.configDir)
+	}
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if executablePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(executablePath))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, filepath.Join(wd, "config"))
+	}
+	if d.app != "" {
+		dirs = append(dirs, filepath.Join("/etc", d.app))
+	}
+	return dirs
 }
 
-// GetProjectRoot returns the project's root directory based on the relative depth specified.
-// The depth parameter specifies how many levels up from the directory of the executable the project root is located.
-func (d *defaultPathResolver) GetProjectRoot(depth int) (string, error) {
-	executablePath, err := os.Executable()
+// GetDefaultConfigPath returns the first existing directory of SearchDirs.
+func (d *defaultPathResolver) GetDefaultConfigPath() (string, error) {
+	dirs := d.SearchDirs()
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", errs.New("no config directory found", "searched", dirs).Wrap()
+}
+
+// GetProjectRoot returns the working directory, the project root when run from its sources.
+func (d *defaultPathResolver) GetProjectRoot() (string, error) {
+	wd, err := os.Getwd()
 	if err != nil {
-		return "", errs.WrapMsg(err, "Executable failed")
+		return "", errs.WrapMsg(err, "Getwd failed")
 	}
+	return wd, nil
+}
 
-	// Move up the specified number of directories to find the project root
-	projectRoot := executablePath
-	for i := 0; i < depth; i++ {
-		projectRoot = filepath.Dir(projectRoot)
+// FindConfigFile returns the path of configName in the first directory of SearchDirs holding it.
+func (d *defaultPathResolver) FindConfigFile(configName string) (string, error) {
+	dirs := d.SearchDirs()
+	for _, dir := range dirs {
+		path := filepath.Join(dir, configName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
 	}
+	return "", errs.New("config file not found", "configName", configName, "searched", dirs).Wrap()
+}
+
+// Profile returns the selected profile, empty when none is.
+func (d *defaultPathResolver) Profile() string {
+	return os.Getenv(d.profileEnv)
+}
 
-	return projectRoot, nil
+// ProfileFile returns the overlay of the file at path for profile: mongodb.prod.yml for
+// mongodb.yml and prod.
+func ProfileFile(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
 }
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathResolverSearchOrder(t *testing.T) {
+	flagDir, envDir := t.TempDir(), t.TempDir()
+	t.Setenv(ConfigDirEnv, envDir)
+	r := NewPathResolver(WithConfigDir(flagDir), WithAppName("openim"))
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{flagDir, envDir, filepath.Dir(exe), filepath.Join(wd, "config"), "/etc/openim"}, r.SearchDirs())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(envDir, "mongodb.yml"), []byte("uri: env"), 0o644))
+	path, err := r.FindConfigFile("mongodb.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(envDir, "mongodb.yml"), path)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(flagDir, "mongodb.yml"), []byte("uri: flag"), 0o644))
+	path, err = r.FindConfigFile("mongodb.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(flagDir, "mongodb.yml"), path)
+
+	_, err = r.FindConfigFile("missing.yml")
+	assert.ErrorContains(t, err, "config file not found")
+
+	dir, err := r.GetDefaultConfigPath()
+	assert.NoError(t, err)
+	assert.Equal(t, flagDir, dir)
+}
+
+func TestLoaderProfileOverlay(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mongodb.yml"), []byte("mongo:\n  address: [a:27017]\n  database: openim\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mongodb.prod.yml"), []byte("mongo:\n  database: openim_prod\n"), 0o644))
+	loader := NewLoader(NewPathResolver(WithConfigDir(dir)))
+
+	var conf testConfig
+	assert.NoError(t, loader.InitConfig(&conf, "mongodb.yml", ""))
+	assert.Equal(t, "openim", conf.Mongo.Database)
+
+	t.Setenv(ProfileEnv, "prod")
+	conf = testConfig{}
+	prov, err := loader.InitConfigWithProvenance(&conf, "mongodb.yml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a:27017"}, conf.Mongo.Address)
+	assert.Equal(t, "openim_prod", conf.Mongo.Database)
+	assert.Equal(t, "file:"+filepath.Join(dir, "mongodb.prod.yml"), prov.Lookup("mongo.database").String())
+	assert.Equal(t, "file:"+filepath.Join(dir, "mongodb.yml"), prov.Lookup("mongo.address").String())
+
+	t.Setenv(ProfileEnv, "staging")
+	conf = testConfig{}
+	assert.NoError(t, loader.InitConfig(&conf, "mongodb.yml", ""))
+	assert.Equal(t, "openim", conf.Mongo.Database)
+
+	assert.Equal(t, "/etc/app/share.prod.json", ProfileFile("/etc/app/share.json", "prod"))
+}
@@ -0,0 +1,120 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+)
+
+// OriginKind is the kind of source a configuration value comes from.
+type OriginKind string
+
+const (
+	OriginDefault  OriginKind = "default"
+	OriginFile     OriginKind = "file"
+	OriginEnv      OriginKind = "env"
+	OriginRegistry OriginKind = "registry"
+	OriginFlag     OriginKind = "flag"
+)
+
+// Origin is the source of a configuration value: the file path, the environment variable or the
+// registry key it was read from.
+type Origin struct {
+	Kind OriginKind
+	Name string
+}
+
+func (o Origin) String() string {
+	if o.Name == "" {
+		return string(o.Kind)
+	}
+	return string(o.Kind) + ":" + o.Name
+}
+
+// Provenance maps the yaml paths of the configuration values, such as mongo.address[0], to their
+// origin. A nil Provenance records nothing.
+type Provenance map[string]Origin
+
+// Lookup returns the origin of the value at path, inherited from its closest recorded parent. A
+// value no source set is a default.
+func (p Provenance) Lookup(path string) Origin {
+	for {
+		if origin, ok := p[path]; ok {
+			return origin
+		}
+		if path == "" {
+			return Origin{Kind: OriginDefault}
+		}
+		path = parentPath(path)
+	}
+}
+
+// set records the origin of the value at path, it replaces the origins of the values below it.
+func (p Provenance) set(path string, origin Origin) {
+	if p == nil {
+		return
+	}
+	for k := range p {
+		if strings.HasPrefix(k, path+".") || strings.HasPrefix(k, path+"[") {
+			delete(p, k)
+		}
+	}
+	p[path] = origin
+}
+
+// setTree records origin for the leaves of t, merged under path as Tree.Merge does.
+func (p Provenance) setTree(t Tree, path string, origin Origin) {
+	if p == nil {
+		return
+	}
+	for k, v := range t {
+		if sub, ok := v.(Tree); ok {
+			p.setTree(sub, joinPath(path, k), origin)
+			continue
+		}
+		p.set(joinPath(path, k), origin)
+	}
+}
+
+// parentPath strips the last name or index of path.
+func parentPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// layerOrigin returns the origin of the values of a Manager layer.
+func layerOrigin(l *layer) Origin {
+	switch s := l.source.(type) {
+	case *FileSystemSource:
+		return Origin{Kind: OriginFile, Name: s.FilePath}
+	case *EnvVarSource:
+		return Origin{Kind: OriginEnv, Name: s.VarName}
+	case *RegistrySource:
+		return Origin{Kind: OriginRegistry, Name: s.Key}
+	}
+	switch l.precedence {
+	case PrecedenceDefaults:
+		return Origin{Kind: OriginDefault}
+	case PrecedenceEnv:
+		return Origin{Kind: OriginEnv}
+	case PrecedenceFlags:
+		return Origin{Kind: OriginFlag}
+	default:
+		return Origin{Kind: OriginFile, Name: sourceName(l.source)}
+	}
+}
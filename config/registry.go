@@ -0,0 +1,72 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// Registry is a central store of configuration documents, such as zookeeper.ZkClient.
+type Registry interface {
+	RegisterConf2Registry(key string, conf []byte) error
+	GetConfFromRegistry(key string) ([]byte, error)
+}
+
+// ConfWatcher is implemented by registries that report the changes of a key.
+type ConfWatcher interface {
+	WatchConf(key string, fn func(conf []byte)) error
+}
+
+// NotifyingSource is a ConfigSource that reports its changes. WatchManager reloads the
+// configuration when such a source changes.
+type NotifyingSource interface {
+	ConfigSource
+	Notify(fn func()) error
+}
+
+// RegistrySource read a configuration from a key of a Registry.
+type RegistrySource struct {
+	Registry Registry
+	Key      string
+}
+
+func (r *RegistrySource) Read() ([]byte, error) {
+	data, err := r.Registry.GetConfFromRegistry(r.Key)
+	return data, errs.WrapMsg(err, "GetConfFromRegistry failed", "key", r.Key)
+}
+
+// Notify calls fn when the key changes, the Registry must implement ConfWatcher.
+func (r *RegistrySource) Notify(fn func()) error {
+	watcher, ok := r.Registry.(ConfWatcher)
+	if !ok {
+		return errs.New("registry cannot watch keys", "key", r.Key).Wrap()
+	}
+	return watcher.WatchConf(r.Key, func([]byte) { fn() })
+}
+
+// PublishFile writes the configuration file at path to the registry under key. The file must
+// parse, see ParserForFile.
+func PublishFile(registry Registry, key, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errs.WrapMsg(err, "ReadFile failed", "path", path)
+	}
+	if _, err := parseTree(ParserForFile(path, data), data); err != nil {
+		return errs.WrapMsg(err, "parse config file failed", "path", path)
+	}
+	return registry.RegisterConf2Registry(key, data)
+}
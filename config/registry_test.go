@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memRegistry struct {
+	confs    map[string][]byte
+	watchers map[string][]func([]byte)
+}
+
+func newMemRegistry() *memRegistry {
+	return &memRegistry{confs: make(map[string][]byte), watchers: make(map[string][]func([]byte))}
+}
+
+func (r *memRegistry) RegisterConf2Registry(key string, conf []byte) error {
+	r.confs[key] = conf
+	for _, fn := range r.watchers[key] {
+		fn(conf)
+	}
+	return nil
+}
+
+func (r *memRegistry) GetConfFromRegistry(key string) ([]byte, error) {
+	return r.confs[key], nil
+}
+
+func (r *memRegistry) WatchConf(key string, fn func(conf []byte)) error {
+	r.watchers[key] = append(r.watchers[key], fn)
+	return nil
+}
+
+func TestRegistrySource(t *testing.T) {
+	registry := newMemRegistry()
+	file := writeFile(t, "log.json", `{"level": 3, "name": "openim"}`)
+	assert.NoError(t, PublishFile(registry, "log", file))
+	assert.Error(t, PublishFile(registry, "log", writeFile(t, "bad.json", `{`)))
+
+	m := NewManager(nil)
+	m.AddSource(&MapSource{Values: map[string]any{"level": 1}}, WithPrecedence(PrecedenceDefaults))
+	m.AddSource(&RegistrySource{Registry: registry, Key: "log"})
+	w, err := WatchManager[testLog](m)
+	assert.NoError(t, err)
+	assert.Equal(t, &testLog{Level: 3, Name: "openim"}, w.Get())
+
+	var news []*testLog
+	w.Subscribe(func(_, new *testLog) { news = append(news, new) })
+	assert.NoError(t, registry.RegisterConf2Registry("log", []byte("name: im\n")))
+	assert.Equal(t, &testLog{Level: 1, Name: "im"}, w.Get())
+	assert.Equal(t, []*testLog{{Level: 1, Name: "im"}}, news)
+
+	// an unparsable update keeps the last good config
+	assert.NoError(t, registry.RegisterConf2Registry("log", []byte("name: [")))
+	assert.Equal(t, &testLog{Level: 1, Name: "im"}, w.Get())
+}
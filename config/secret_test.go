@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSecrets struct {
+	Mongo struct {
+		Address  []string `yaml:"address"`
+		Username string   `yaml:"username"`
+		Password string   `yaml:"password"`
+		URI      string   `yaml:"uri"`
+	} `yaml:"mongo"`
+	Redis struct {
+		Password string            `yaml:"password"`
+		Extra    map[string]string `yaml:"extra"`
+	} `yaml:"redis"`
+}
+
+func TestResolveSecrets(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	enc, err := EncryptValue("redis-pass", key)
+	assert.NoError(t, err)
+	secretFile := writeFile(t, "mongo-password", "mongo-pass\n")
+	keyFile := writeFile(t, "config-key", string(key)+"\n")
+	t.Setenv(SecretKeyFileEnv, keyFile)
+	t.Setenv("TEST_MONGO_USER", "openim")
+
+	file := writeFile(t, "app.yml", `
+mongo:
+  address: [a:27017]
+  username: ${env:TEST_MONGO_USER}
+  password: ${file:`+secretFile+`}
+  uri: mongodb://${env:TEST_MONGO_USER}@a:27017/${TEMPLATE}
+redis:
+  password: `+enc+`
+  extra:
+    token: ${env:TEST_MONGO_USER}
+`)
+	var conf testSecrets
+	assert.NoError(t, NewLoader(nil).InitConfig(&conf, filepath.Base(file), filepath.Dir(file)))
+	assert.Equal(t, "openim", conf.Mongo.Username)
+	assert.Equal(t, "mongo-pass", conf.Mongo.Password)
+	assert.Equal(t, "mongodb://openim@a:27017/${TEMPLATE}", conf.Mongo.URI)
+	assert.Equal(t, "redis-pass", conf.Redis.Password)
+	assert.Equal(t, map[string]string{"token": "openim"}, conf.Redis.Extra)
+
+	assert.NoError(t, os.WriteFile(file, []byte("mongo:\n  address: [\"${env:TEST_UNSET_VAR}\"]\n"), 0o644))
+	err = NewLoader(nil).InitConfig(&testSecrets{}, filepath.Base(file), filepath.Dir(file))
+	assert.ErrorContains(t, err, "mongo.address[0]")
+	assert.ErrorContains(t, err, "TEST_UNSET_VAR")
+
+	assert.NoError(t, os.WriteFile(file, []byte("redis:\n  password: enc:bm90LWVuY3J5cHRlZA==\n"), 0o644))
+	err = NewLoader(nil).InitConfig(&testSecrets{}, filepath.Base(file), filepath.Dir(file))
+	assert.ErrorContains(t, err, "redis.password")
+
+	t.Setenv(SecretKeyFileEnv, "")
+	assert.NoError(t, os.WriteFile(file, []byte("redis:\n  password: "+enc+"\n"), 0o644))
+	err = NewLoader(nil).InitConfig(&testSecrets{}, filepath.Base(file), filepath.Dir(file))
+	assert.ErrorContains(t, err, "secret key not set")
+
+	_, err = EncryptValue("x", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestDecryptValueWrongKey(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	enc, err := EncryptValue("redis-pass", key)
+	assert.NoError(t, err)
+	again, err := EncryptValue("redis-pass", key)
+	assert.NoError(t, err)
+	assert.NotEqual(t, enc, again)
+
+	plain, err := DecryptValue(enc, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "redis-pass", plain)
+	for i := 0; i < 64; i++ {
+		wrong := []byte(fmt.Sprintf("wrong-key-%06d", i))
+		_, err := DecryptValue(enc, wrong)
+		assert.Error(t, err, "key %s", wrong)
+	}
+}
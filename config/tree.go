@@ -0,0 +1,95 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/Meikwei/go-tools/errs"
+	"gopkg.in/yaml.v2"
+)
+
+// Tree is the generic form of a configuration document: nested maps keyed by the yaml names of
+// the fields, with slices and scalar leaves.
+type Tree map[string]any
+
+// parseTree parses data into a Tree with parser.
+func parseTree(parser Parser, data []byte) (Tree, error) {
+	var raw map[string]any
+	if err := parser.Parse(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalize(raw).(Tree), nil
+}
+
+// normalize converts the map[interface{}]interface{} values produced by yaml.v2 into Trees.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case Tree:
+		for k, child := range val {
+			val[k] = normalize(child)
+		}
+		return val
+	case map[string]any:
+		tree := make(Tree, len(val))
+		for k, child := range val {
+			tree[k] = normalize(child)
+		}
+		return tree
+	case map[any]any:
+		tree := make(Tree, len(val))
+		for k, child := range val {
+			tree[fmt.Sprint(k)] = normalize(child)
+		}
+		return tree
+	case []any:
+		for i, child := range val {
+			val[i] = normalize(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// Merge deep-merges src into t, values of src win. Nested maps are merged key by key, any other
+// value, slices included, replaces the value of t.
+func (t Tree) Merge(src Tree) {
+	for k, v := range src {
+		if sub, ok := v.(Tree); ok {
+			if dst, ok := t[k].(Tree); ok {
+				dst.Merge(sub)
+				continue
+			}
+			clone := make(Tree, len(sub))
+			clone.Merge(sub)
+			t[k] = clone
+			continue
+		}
+		t[k] = v
+	}
+}
+
+// Decode decodes t into config through its yaml tags.
+func (t Tree) Decode(config any) error {
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return errs.WrapMsg(err, "marshal config tree failed")
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return errs.WrapMsg(err, "failed to unmarshal config data")
+	}
+	return nil
+}
@@ -0,0 +1,468 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"cmp"
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+const (
+	// DefaultTag holds the value of a field left empty by the configuration.
+	DefaultTag = "default"
+	// ValidateTag holds the comma separated rules of a field.
+	ValidateTag = "validate"
+)
+
+// Violation is one rule a configuration field does not satisfy.
+type Violation struct {
+	Path    string // yaml path such as mongo.address[0]
+	Rule    string
+	Message string
+}
+
+// Errors lists every violation found in a configuration.
+type Errors struct {
+	Violations []*Violation
+}
+
+func (e *Errors) add(path, rule, message string) {
+	e.Violations = append(e.Violations, &Violation{Path: path, Rule: rule, Message: message})
+}
+
+func (e *Errors) err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Error() string {
+	v := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		v = append(v, violation.Path+" "+violation.Message)
+	}
+	return "validation failed: " + strings.Join(v, "; ")
+}
+
+// TagValidator validates a configuration with the rules of its validate tags, walking nested
+// structs, pointers, slices and maps:
+//
+//	Addr    []string      `yaml:"address" validate:"required,min=1"`
+//	Level   string        `yaml:"level" default:"info" validate:"oneof=debug info warn error"`
+//	Timeout time.Duration `yaml:"timeout" default:"5s" validate:"min=1s,max=1m"`
+//
+// The rules are required, min=n and max=n (the value of numbers and durations, the length of
+// strings, slices and maps), oneof=a b c, url, hostport and file (an existing regular file).
+// Except required, the rules of an empty field are skipped. When the configuration is a pointer
+// the default tags are applied first, see ApplyDefaults.
+type TagValidator struct{}
+
+// NewTagValidator creates and returns an instance of TagValidator.
+func NewTagValidator() *TagValidator {
+	return &TagValidator{}
+}
+
+// Validate applies the defaults of config, then returns an *Errors listing every violation.
+func (v *TagValidator) Validate(config any) error {
+	val := reflect.ValueOf(config)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		if err := ApplyDefaults(config); err != nil {
+			return err
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return errs.New("validation failed: config must be a struct or a pointer to struct").Wrap()
+	}
+	e := &Errors{}
+	if err := validateValue(e, val, ""); err != nil {
+		return err
+	}
+	return e.err()
+}
+
+// ApplyDefaults sets the zero fields of config holding a default tag, walking nested structs.
+// Slices of scalars take a comma separated list. A nil pointer to a struct with defaults in its
+// subtree is allocated, except the pointers of a recursive type nested in their own allocation.
+func ApplyDefaults(config any) error {
+	val := reflect.ValueOf(config)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errs.New("config must be a non-nil pointer").Wrap()
+	}
+	return applyDefaults(val.Elem(), "", make(map[reflect.Type]bool))
+}
+
+// applyDefaults sets the defaults under v, allocating holds the struct types allocated on the
+// current path.
+func applyDefaults(v reflect.Value, path string, allocating map[reflect.Type]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return applyDefaults(v.Elem(), path, allocating)
+		}
+		elemType := v.Type().Elem()
+		if !v.CanSet() || elemType.Kind() != reflect.Struct || allocating[elemType] ||
+			!hasDefaults(elemType, make(map[reflect.Type]bool)) {
+			return nil
+		}
+		elem := reflect.New(elemType)
+		allocating[elemType] = true
+		err := applyDefaults(elem.Elem(), path, allocating)
+		delete(allocating, elemType)
+		if err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath, ok := fieldPath(path, field)
+			if !ok {
+				continue
+			}
+			fv := v.Field(i)
+			if def, ok := field.Tag.Lookup(DefaultTag); ok && fv.IsZero() {
+				if err := setDefault(fv, def); err != nil {
+					return errs.WrapMsg(err, "invalid default value", "field", fieldPath, "default", def)
+				}
+			}
+			if err := applyDefaults(fv, fieldPath, allocating); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := applyDefaults(v.Index(i), fmt.Sprintf("%s[%d]", path, i), allocating); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Struct && v.Type().Elem().Kind() != reflect.Ptr {
+			return nil
+		}
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := applyDefaults(elem, fmt.Sprintf("%s[%v]", path, key.Interface()), allocating); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	}
+	return nil
+}
+
+// hasDefaults reports whether the struct t or the structs and struct pointers it holds have a
+// field with a default tag, seen breaks the cycles of recursive types.
+func hasDefaults(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := fieldPath("", field); !ok {
+			continue
+		}
+		if _, ok := field.Tag.Lookup(DefaultTag); ok {
+			return true
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasDefaults(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldPath returns the yaml path of field under path, false when the field is not decoded.
+func fieldPath(path string, field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if strings.Contains(opts, "inline") {
+		return path, true
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	if path == "" {
+		return name, true
+	}
+	return path + "." + name, true
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func setDefault(v reflect.Value, raw string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setDefault(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseInt(v.Type(), raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setDefault(s.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return errs.New("unsupported default type", "type", v.Type()).Wrap()
+	}
+	return nil
+}
+
+// parseInt parses an integer of type t, durations use time.ParseDuration.
+func parseInt(t reflect.Type, raw string) (int64, error) {
+	if t == durationType {
+		d, err := time.ParseDuration(raw)
+		return int64(d), err
+	}
+	return strconv.ParseInt(raw, 10, t.Bits())
+}
+
+func validateValue(e *Errors, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return validateValue(e, v.Elem(), path)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath, ok := fieldPath(path, field)
+			if !ok {
+				continue
+			}
+			if tag := field.Tag.Get(ValidateTag); tag != "" && tag != "-" {
+				if err := validateField(e, v.Field(i), fieldPath, tag); err != nil {
+					return err
+				}
+			}
+			if err := validateValue(e, v.Field(i), fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(e, v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if err := validateValue(e, v.MapIndex(key), fmt.Sprintf("%s[%v]", path, key.Interface())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField checks the rules of tag on v and records the first one failing. A malformed tag
+// is returned as an error.
+func validateField(e *Errors, v reflect.Value, path, tag string) error {
+	empty := isEmpty(v)
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			if empty {
+				e.add(path, name, "is required")
+				return nil
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+		message, err := checkRule(v, name, param)
+		if err != nil {
+			return errs.WrapMsg(err, "invalid validate tag", "field", path, "rule", rule)
+		}
+		if message != "" {
+			e.add(path, name, message)
+			return nil
+		}
+	}
+	return nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// checkRule returns the message of a failed rule, empty when v satisfies it.
+func checkRule(v reflect.Value, name, param string) (string, error) {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch name {
+	case "min", "max":
+		cmp, err := compare(v, param)
+		if err != nil {
+			return "", err
+		}
+		if name == "min" && cmp < 0 {
+			return "must be at least " + param, nil
+		}
+		if name == "max" && cmp > 0 {
+			return "must be at most " + param, nil
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return "", nil
+			}
+		}
+		return "must be one of [" + param + "]", nil
+	case "url":
+		if v.Kind() != reflect.String {
+			return "", errs.New("url rule requires a string").Wrap()
+		}
+		if u, err := url.Parse(v.String()); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute url", nil
+		}
+	case "hostport":
+		if v.Kind() != reflect.String {
+			return "", errs.New("hostport rule requires a string").Wrap()
+		}
+		_, port, err := net.SplitHostPort(v.String())
+		if err != nil {
+			return "must be a host:port address", nil
+		}
+		if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+			return "must have a port between 1 and 65535", nil
+		}
+	case "file":
+		if v.Kind() != reflect.String {
+			return "", errs.New("file rule requires a string").Wrap()
+		}
+		if info, err := os.Stat(v.String()); err != nil || !info.Mode().IsRegular() {
+			return "must be an existing file", nil
+		}
+	default:
+		return "", errs.New("unknown validate rule", "rule", name).Wrap()
+	}
+	return "", nil
+}
+
+// compare compares the value of numbers and durations, or the length of strings, slices and maps,
+// with param.
+func compare(v reflect.Value, param string) (int, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return compareInt(int64(utf8.RuneCountInString(v.String())), param)
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return compareInt(int64(v.Len()), param)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseInt(v.Type(), param)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Int(), n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Uint(), n), nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, err
+		}
+		return cmp.Compare(v.Float(), f), nil
+	default:
+		return 0, errs.New("min and max rules do not support type", "type", v.Type()).Wrap()
+	}
+}
+
+func compareInt(n int64, param string) (int, error) {
+	limit, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return cmp.Compare(n, limit), nil
+}
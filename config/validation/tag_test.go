@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tagMongo struct {
+	Address  []string      `yaml:"address" validate:"required,min=1"`
+	Database string        `yaml:"database" default:"openim"`
+	Timeout  time.Duration `yaml:"timeout" default:"5s" validate:"min=1s,max=1m"`
+}
+
+type tagConfig struct {
+	Mongo tagMongo `yaml:"mongo"`
+	Log   struct {
+		Level   string `yaml:"level" default:"info" validate:"oneof=debug info warn error"`
+		Storage string `yaml:"storage" validate:"file"`
+	} `yaml:"log"`
+	Endpoints []struct {
+		URL  string `yaml:"url" validate:"required,url"`
+		Addr string `yaml:"addr" validate:"hostport"`
+	} `yaml:"endpoints"`
+	Ports   []int `yaml:"ports" default:"10001,10002" validate:"max=3"`
+	Workers *int  `yaml:"workers" default:"4" validate:"min=1"`
+}
+
+func TestTagValidatorDefaults(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "log.conf")
+	assert.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	var conf tagConfig
+	conf.Mongo.Address = []string{"a:27017"}
+	conf.Log.Storage = file
+	assert.NoError(t, NewTagValidator().Validate(&conf))
+	assert.Equal(t, "openim", conf.Mongo.Database)
+	assert.Equal(t, 5*time.Second, conf.Mongo.Timeout)
+	assert.Equal(t, "info", conf.Log.Level)
+	assert.Equal(t, []int{10001, 10002}, conf.Ports)
+	assert.Equal(t, 4, *conf.Workers)
+}
+
+func TestTagValidatorViolations(t *testing.T) {
+	var conf tagConfig
+	conf.Mongo.Timeout = time.Hour
+	conf.Log.Level = "trace"
+	conf.Log.Storage = filepath.Join(t.TempDir(), "missing.conf")
+	conf.Endpoints = []struct {
+		URL  string `yaml:"url" validate:"required,url"`
+		Addr string `yaml:"addr" validate:"hostport"`
+	}{{URL: "http://a", Addr: "a:1"}, {URL: "/relative", Addr: "a"}, {Addr: "a:70000"}}
+	conf.Ports = []int{1, 2, 3, 4}
+
+	err := NewTagValidator().Validate(&conf)
+	var e *Errors
+	assert.True(t, errors.As(err, &e))
+	got := map[string]string{}
+	for _, v := range e.Violations {
+		got[v.Path] = v.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"mongo.address":     "required",
+		"mongo.timeout":     "max",
+		"log.level":         "oneof",
+		"log.storage":       "file",
+		"endpoints[1].url":  "url",
+		"endpoints[1].addr": "hostport",
+		"endpoints[2].url":  "required",
+		"endpoints[2].addr": "hostport",
+		"ports":             "max",
+	}, got)
+	assert.Contains(t, err.Error(), "mongo.address is required")
+}
+
+func TestTagValidatorInvalidTag(t *testing.T) {
+	type config struct {
+		Name string `yaml:"name" validate:"uppercase"`
+		Size int    `yaml:"size" default:"big"`
+	}
+	err := NewTagValidator().Validate(&config{Name: "a", Size: 1})
+	assert.ErrorContains(t, err, "unknown validate rule")
+	err = NewTagValidator().Validate(&config{})
+	assert.ErrorContains(t, err, "invalid default value")
+	assert.ErrorContains(t, err, "size")
+}
+
+type tagNode struct {
+	Name string   `yaml:"name" default:"node"`
+	Next *tagNode `yaml:"next"`
+}
+
+func TestApplyDefaultsNilPointers(t *testing.T) {
+	type plain struct {
+		Name string `yaml:"name"`
+	}
+	type config struct {
+		Redis *struct {
+			Address string `yaml:"address" default:"127.0.0.1:6379"`
+		} `yaml:"redis"`
+		Plain *plain   `yaml:"plain"`
+		Node  *tagNode `yaml:"node"`
+	}
+	var conf config
+	assert.NoError(t, ApplyDefaults(&conf))
+	if assert.NotNil(t, conf.Redis) {
+		assert.Equal(t, "127.0.0.1:6379", conf.Redis.Address)
+	}
+	assert.Nil(t, conf.Plain)
+	// A recursive type is allocated once, not along its own pointers.
+	if assert.NotNil(t, conf.Node) {
+		assert.Equal(t, "node", conf.Node.Name)
+		assert.Nil(t, conf.Node.Next)
+	}
+}
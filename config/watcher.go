@@ -0,0 +1,305 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Meikwei/go-tools/config/validation"
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/log"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+// WatchOption configures a Watcher.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval  time.Duration
+	validator validation.Validator
+}
+
+// WithWatchInterval sets how often the files are polled, 5 seconds by default. The interval must
+// be positive.
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = interval
+	}
+}
+
+// WithValidator validates every loaded configuration, an invalid one is rejected.
+func WithValidator(validator validation.Validator) WatchOption {
+	return func(o *watchOptions) {
+		o.validator = validator
+	}
+}
+
+// fileState identifies the content of a watched file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	exists  bool
+}
+
+// Watcher holds the current snapshot of a configuration of type T and reloads it when one of
+// its files changes. Files are polled by modification time, then compared by content hash.
+// A configuration that fails to load or validate is logged and the last good one is kept.
+type Watcher[T any] struct {
+	load  func(*T) error
+	files []string
+	opts  watchOptions
+
+	current atomic.Pointer[T]
+	states  []fileState
+
+	lock        sync.Mutex
+	reloadLock  sync.Mutex // serializes the reloads of files and notifying sources
+	subscribers map[int]func(old, new *T)
+	nextID      int
+	pending     []change[T] // reloads not yet delivered to the subscribers
+	notifying   bool        // a Reload is delivering pending
+}
+
+// change is a reload waiting to be delivered to the subscribers.
+type change[T any] struct {
+	old, new *T
+}
+
+// NewWatcher loads a first configuration with load and returns a Watcher reloading it when one of
+// files changes. Start begins the polling.
+func NewWatcher[T any](load func(*T) error, files []string, opts ...WatchOption) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		load:        load,
+		files:       files,
+		opts:        watchOptions{interval: defaultWatchInterval},
+		states:      make([]fileState, len(files)),
+		subscribers: make(map[int]func(old, new *T)),
+	}
+	for _, opt := range opts {
+		opt(&w.opts)
+	}
+	if w.opts.interval <= 0 {
+		return nil, errs.New("watch interval must be positive", "interval", w.opts.interval).Wrap()
+	}
+	for i, file := range files {
+		state, err := readFileState(file)
+		if err != nil {
+			return nil, err
+		}
+		w.states[i] = state
+	}
+	conf, err := w.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(conf)
+	return w, nil
+}
+
+// WatchManager watches the FileSystemSource files of m, reloading with m.Load. The configuration is
+// also reloaded when a NotifyingSource of m, such as a RegistrySource, reports a change.
+func WatchManager[T any](m *Manager, opts ...WatchOption) (*Watcher[T], error) {
+	var (
+		files     []string
+		notifiers []NotifyingSource
+	)
+	for _, l := range m.sources {
+		switch source := l.source.(type) {
+		case *FileSystemSource:
+			files = append(files, source.FilePath)
+		case NotifyingSource:
+			notifiers = append(notifiers, source)
+		}
+	}
+	w, err := NewWatcher(func(conf *T) error { return m.Load(conf) }, files, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range notifiers {
+		err := source.Notify(func() {
+			if err := w.Reload(); err != nil {
+				log.ZWarn(context.Background(), "config reload rejected, keeping the last good config", err, "source", sourceName(source))
+			}
+		})
+		if err != nil {
+			return nil, errs.WrapMsg(err, "watch config source failed", "source", sourceName(source))
+		}
+	}
+	return w, nil
+}
+
+// WatchLoader watches the file loaded by c.InitConfig with the same arguments, and its profile
+// overlay.
+func WatchLoader[T any](c *Loader, configName, configFolderPath string, opts ...WatchOption) (*Watcher[T], error) {
+	file, err := c.resolveConfigPath(configName, configFolderPath)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "resolveConfigPath failed", "configName", configName, "configFolderPath", configFolderPath)
+	}
+	files := []string{file}
+	if overlay := c.profileFile(file); overlay != "" {
+		files = append(files, overlay)
+	}
+	return NewWatcher(func(conf *T) error { return c.InitConfig(conf, configName, configFolderPath) }, files, opts...)
+}
+
+// Get returns the current configuration, it must not be modified.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called with the previous and the new configuration after each
+// reload, in the order of the reloads. The returned function removes the subscription. fn may
+// call Reload, the nested reload is then delivered once fn and the other subscribers return.
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+	return func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Start polls the files until ctx is done.
+func (w *Watcher[T]) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.opts.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := w.Check(); err != nil {
+					log.ZWarn(ctx, "config reload rejected, keeping the last good config", err, "files", w.files)
+				}
+			}
+		}
+	}()
+}
+
+// Check polls the files once and reloads the configuration when one of them changed. It reports
+// whether a change was found, an error means it was rejected and the last good config is kept.
+func (w *Watcher[T]) Check() (bool, error) {
+	w.lock.Lock()
+	changed := false
+	for i, file := range w.files {
+		state, err := statFile(file, w.states[i])
+		if err != nil {
+			w.lock.Unlock()
+			return false, err
+		}
+		if state.exists != w.states[i].exists || state.hash != w.states[i].hash {
+			changed = true
+		}
+		w.states[i] = state
+	}
+	w.lock.Unlock()
+	if !changed {
+		return false, nil
+	}
+	return true, w.Reload()
+}
+
+// Reload loads and validates the configuration, then swaps it in and notifies the subscribers.
+// The subscribers run without reloadLock held, so that they may reload in turn; the change is
+// queued and delivered by the Reload already notifying, if any.
+func (w *Watcher[T]) Reload() error {
+	w.reloadLock.Lock()
+	conf, err := w.loadConfig()
+	if err != nil {
+		w.reloadLock.Unlock()
+		return err
+	}
+	old := w.current.Swap(conf)
+	w.lock.Lock()
+	w.pending = append(w.pending, change[T]{old: old, new: conf})
+	w.reloadLock.Unlock()
+	if w.notifying {
+		w.lock.Unlock()
+		return nil
+	}
+	w.notifying = true
+	for len(w.pending) > 0 {
+		c := w.pending[0]
+		w.pending = w.pending[1:]
+		subscribers := make([]func(old, new *T), 0, len(w.subscribers))
+		for _, fn := range w.subscribers {
+			subscribers = append(subscribers, fn)
+		}
+		w.lock.Unlock()
+		for _, fn := range subscribers {
+			fn(c.old, c.new)
+		}
+		w.lock.Lock()
+	}
+	w.notifying = false
+	w.lock.Unlock()
+	return nil
+}
+
+func (w *Watcher[T]) loadConfig() (*T, error) {
+	conf := new(T)
+	if err := w.load(conf); err != nil {
+		return nil, err
+	}
+	if w.opts.validator != nil {
+		if err := w.opts.validator.Validate(conf); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// statFile returns the state of file, the content is only hashed when its modification time or
+// size differ from prev.
+func statFile(file string, prev fileState) (fileState, error) {
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, errs.WrapMsg(err, "Stat failed", "file", file)
+	}
+	if prev.exists && info.ModTime().Equal(prev.modTime) && info.Size() == prev.size {
+		return prev, nil
+	}
+	return readFileState(file)
+}
+
+func readFileState(file string) (fileState, error) {
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, errs.WrapMsg(err, "Stat failed", "file", file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fileState{}, errs.WrapMsg(err, "ReadFile failed", "file", file)
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(data), exists: true}, nil
+}
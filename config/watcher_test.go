@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Meikwei/go-tools/config/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLog struct {
+	Level int    `yaml:"level"`
+	Name  string `yaml:"name"`
+}
+
+func TestWatcher(t *testing.T) {
+	file := writeFile(t, "log.yml", "level: 3\nname: openim\n")
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: file})
+	w, err := WatchManager[testLog](m, WithValidator(validation.NewSimpleValidator()))
+	assert.NoError(t, err)
+	assert.Equal(t, &testLog{Level: 3, Name: "openim"}, w.Get())
+
+	var olds, news []*testLog
+	unsubscribe := w.Subscribe(func(old, new *testLog) {
+		olds = append(olds, old)
+		news = append(news, new)
+	})
+
+	changed, err := w.Check()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// touched without change
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(file, later, later))
+	changed, err = w.Check()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	assert.NoError(t, os.WriteFile(file, []byte("level: 6\nname: openim\n"), 0o644))
+	changed, err = w.Check()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 6, w.Get().Level)
+	assert.Equal(t, []*testLog{{Level: 3, Name: "openim"}}, olds)
+	assert.Equal(t, []*testLog{{Level: 6, Name: "openim"}}, news)
+
+	// an invalid update keeps the last good config
+	assert.NoError(t, os.WriteFile(file, []byte("level: 0\nname: openim\n"), 0o644))
+	changed, err = w.Check()
+	assert.Error(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 6, w.Get().Level)
+	assert.NoError(t, os.WriteFile(file, []byte("level: [\n"), 0o644))
+	_, err = w.Check()
+	assert.Error(t, err)
+	assert.Equal(t, 6, w.Get().Level)
+	assert.Len(t, news, 1)
+
+	unsubscribe()
+	assert.NoError(t, os.WriteFile(file, []byte("level: 1\nname: im\n"), 0o644))
+	changed, err = w.Check()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, &testLog{Level: 1, Name: "im"}, w.Get())
+	assert.Len(t, news, 1)
+}
+
+func TestWatcherInterval(t *testing.T) {
+	file := writeFile(t, "log.yml", "level: 3\n")
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: file})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err := WatchManager[testLog](m, WithWatchInterval(interval))
+		assert.Error(t, err)
+	}
+	_, err := WatchManager[testLog](m, WithWatchInterval(time.Millisecond))
+	assert.NoError(t, err)
+}
+
+func TestWatcherSubscriberReloads(t *testing.T) {
+	file := writeFile(t, "log.yml", "level: 3\n")
+	m := NewManager(&YAMLParser{})
+	m.AddSource(&FileSystemSource{FilePath: file})
+	w, err := WatchManager[testLog](m)
+	assert.NoError(t, err)
+
+	var levels [][2]int
+	w.Subscribe(func(old, new *testLog) {
+		levels = append(levels, [2]int{old.Level, new.Level})
+		if new.Level == 6 {
+			assert.NoError(t, os.WriteFile(file, []byte("level: 7\n"), 0o644))
+			assert.NoError(t, w.Reload())
+		}
+	})
+
+	done := make(chan error)
+	go func() {
+		assert.NoError(t, os.WriteFile(file, []byte("level: 6\n"), 0o644))
+		done <- w.Reload()
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload from a subscriber deadlocked")
+	}
+	assert.Equal(t, [][2]int{{3, 6}, {6, 7}}, levels)
+	assert.Equal(t, 7, w.Get().Level)
+}
@@ -15,6 +15,7 @@
 package zookeeper
 
 import (
+	"context"
 	"time"
 
 	"github.com/Meikwei/go-tools/errs"
@@ -57,3 +58,90 @@ func (s *ZkClient) GetConfFromRegistry(key string) ([]byte, error) {
 	}
 	return bytes, nil
 }
+
+// WatchConf 监听注册中心中 key 对应的配置，配置每次变化时以新值调用 fn。
+// 节点被删除后监听依然有效，节点重新创建时会再次调用 fn。会话过期后监听会在新会话中
+// 重新设置，并以最新的配置调用一次 fn。
+func (s *ZkClient) WatchConf(key string, fn func(conf []byte)) error {
+	path := s.getPath(key)
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	if _, _, err := s.watchConfPath(path); err != nil {
+		return err
+	}
+	s.confWatchers[path] = append(s.confWatchers[path], fn)
+	return nil
+}
+
+// watchConfPath 读取配置节点并设置数据监听，节点不存在时监听其创建。
+func (s *ZkClient) watchConfPath(path string) ([]byte, bool, error) {
+	data, _, _, err := s.conn.GetW(path)
+	if err == nil {
+		return data, true, nil
+	}
+	if err != zk.ErrNoNode {
+		return nil, false, errs.WrapMsg(err, "GetW failed", "path", path)
+	}
+	exists, _, _, err := s.conn.ExistsW(path)
+	if err != nil {
+		return nil, false, errs.WrapMsg(err, "ExistsW failed", "path", path)
+	}
+	if exists {
+		// 节点在两次调用之间被创建
+		return s.watchConfPath(path)
+	}
+	return nil, false, nil
+}
+
+// confChanged 在配置节点变化后重新设置监听并通知监听者，在独立的协程中执行以免阻塞事件处理。
+func (s *ZkClient) confChanged(ctx context.Context, path string) {
+	s.confLock.Lock()
+	_, ok := s.confWatchers[path]
+	s.confLock.Unlock()
+	if !ok {
+		return
+	}
+	go func() {
+		s.confLock.Lock()
+		defer s.confLock.Unlock()
+		data, exists, err := s.watchConfPath(path)
+		if err != nil {
+			s.logger.Error(ctx, "zk watch conf failed", err, "path", path)
+			return
+		}
+		if !exists {
+			return
+		}
+		for _, fn := range s.confWatchers[path] {
+			fn(data)
+		}
+	}()
+}
+
+// confWatchLost 在配置节点的监听因会话过期失效后调用，标记在新会话建立时重新设置监听。
+func (s *ZkClient) confWatchLost(path string) {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	if _, ok := s.confWatchers[path]; ok {
+		s.confRearm = true
+	}
+}
+
+// rearmConfWatches 在新会话建立后重新设置失效的配置监听，并以最新的配置通知监听者，
+// 这样会话过期期间的变更也不会丢失。
+func (s *ZkClient) rearmConfWatches(ctx context.Context) {
+	s.confLock.Lock()
+	if !s.confRearm {
+		s.confLock.Unlock()
+		return
+	}
+	s.confRearm = false
+	paths := make([]string, 0, len(s.confWatchers))
+	for path := range s.confWatchers {
+		paths = append(paths, path)
+	}
+	s.confLock.Unlock()
+	for _, path := range paths {
+		s.confChanged(ctx, path)
+	}
+}
@@ -65,6 +65,8 @@ func (s *ZkClient) watch(ctx context.Context) {
 							s.node = node
 						}
 					}
+					// 会话过期后重新设置配置监听。
+					s.rearmConfWatches(ctx)
 				case zk.StateDisconnected:
 					// 会话断开。
 					s.isStateDisconnected = true
@@ -87,11 +89,19 @@ func (s *ZkClient) watch(ctx context.Context) {
 				}
 				s.logger.Debug(ctx, "zk event handle success", "path", event.Path)
 			case zk.EventNodeDataChanged:
+				// 数据变化事件，通知配置监听者。
+				s.logger.Debug(ctx, "zk node data changed event", "event", event)
+				s.confChanged(ctx, event.Path)
 			case zk.EventNodeCreated:
-				// 节点创建事件，记录日志。
+				// 节点创建事件，记录日志并通知配置监听者。
 				s.logger.Debug(ctx, "zk node create event", "event", event)
+				s.confChanged(ctx, event.Path)
 			case zk.EventNodeDeleted:
+				// 节点删除事件，重新设置配置监听以等待节点重新创建。
+				s.confChanged(ctx, event.Path)
 			case zk.EventNotWatching:
+				// 会话过期使监听失效，等待新会话建立后重新设置配置监听。
+				s.confWatchLost(event.Path)
 			}
 		}
 	}
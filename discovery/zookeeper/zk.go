@@ -62,6 +62,10 @@ type ZkClient struct {
 	balancerName        string             // 平衡器名称
 
 	logger log.Logger // 日志记录器
+
+	confLock     sync.Mutex                   // 配置监听锁
+	confWatchers map[string][]func(conf []byte) // 配置路径到变更回调的映射
+	confRearm    bool                         // 会话过期后配置监听是否需要重新设置
 }
 
 // NewZkClient 初始化一个新的ZkClient实例并建立与ZooKeeper的连接
@@ -76,6 +80,8 @@ func NewZkClient(ZkServers []string, scheme string, options ...ZkOption) (*ZkCli
 		resolvers:  make(map[string]*Resolver),
 		lock:       &sync.Mutex{},
 		logger:     nilLog{},
+
+		confWatchers: make(map[string][]func(conf []byte)),
 	}
 	for _, option := range options {
 		option(client)
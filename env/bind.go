@@ -0,0 +1,337 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+const (
+	// TagName 是环境变量名的标签，如 `env:"PORT,required"`。
+	TagName = "env"
+	// DefaultTagName 是变量未设置时使用的默认值标签。
+	DefaultTagName = "default"
+	// SepTagName 是切片和 map 元素分隔符的标签，默认为 ","。
+	SepTagName = "sep"
+	// LayoutTagName 是 time.Time 的时间格式标签，默认为 time.RFC3339。
+	LayoutTagName = "layout"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindErrors 汇总了 Bind 过程中的所有解析错误和必填错误。
+type BindErrors struct {
+	Errors []error
+}
+
+func (e *BindErrors) Error() string {
+	v := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		v = append(v, err.Error())
+	}
+	return "bind environment variables failed: " + strings.Join(v, "; ")
+}
+
+// Unwrap 返回所有错误，供 errors.Is 和 errors.As 使用。
+func (e *BindErrors) Unwrap() []error {
+	return e.Errors
+}
+
+// Bind 根据结构体字段的标签，从环境变量中填充 v 指向的结构体。
+// 变量名为 prefix 与 env 标签名以 "_" 连接，带 env 标签的嵌套结构体以其标签名作为子前缀，
+// 不带标签的嵌套结构体沿用当前前缀：
+//
+//	type Config struct {
+//		Port    int           `env:"PORT,required"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+//		Hosts   []string      `env:"HOSTS" sep:","`
+//		Mongo   struct {
+//			URI *url.URL `env:"URI"`
+//		} `env:"MONGO"`
+//	}
+//
+// env.Bind(&cfg, "APP") 读取 APP_PORT、APP_TIMEOUT、APP_HOSTS 和 APP_MONGO_URI。
+// 支持基本类型、time.Duration、time.Time（layout 标签）、url.URL、切片、
+// 键值以 "=" 分隔的 map 以及实现 encoding.TextUnmarshaler 的类型。
+// 为 nil 的嵌套结构体指针只在其中有环境变量被设置时才分配，自引用的类型不会被递归绑定。
+// 因此这类指针表示可选的配置段：其中一个变量都未设置时，段内 required 字段的错误不会报告；
+// 只要设置了其中任意一个变量，缺失的 required 字段就会报错。需要始终校验的配置段应使用
+// 非指针的结构体，或在 Bind 之前分配指针。
+// 所有错误汇总为一个 *BindErrors 返回。
+func Bind(v any, prefix string) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errs.New("bind target must be a non-nil pointer to struct").Wrap()
+	}
+	b := &binder{visiting: make(map[reflect.Type]bool)}
+	b.bindStruct(val.Elem(), prefix)
+	if len(b.errs) > 0 {
+		return &BindErrors{Errors: b.errs}
+	}
+	return nil
+}
+
+type binder struct {
+	errs []error
+	// matched 表示是否读取到了已设置的环境变量
+	matched bool
+	// visiting 是正在绑定的结构体类型，用于避免自引用类型无限递归
+	visiting map[reflect.Type]bool
+}
+
+func joinName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+func (b *binder) bindStruct(v reflect.Value, prefix string) {
+	t := v.Type()
+	if b.visiting[t] {
+		return
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, hasTag := field.Tag.Lookup(TagName)
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if isNested(field.Type) {
+			nested := prefix
+			if name != "" {
+				nested = joinName(prefix, name)
+			}
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					b.bindPtr(fv, nested)
+					continue
+				}
+				fv = fv.Elem()
+			}
+			b.bindStruct(fv, nested)
+			continue
+		}
+		if !hasTag || name == "" {
+			continue
+		}
+		key := joinName(prefix, name)
+		raw, ok := lookup(key)
+		if ok {
+			b.matched = true
+		} else {
+			raw, ok = field.Tag.Lookup(DefaultTagName)
+		}
+		if !ok {
+			if hasOption(opts, "required") {
+				b.errs = append(b.errs, errs.New("required environment variable not set", "name", key).Wrap())
+			}
+			continue
+		}
+		if err := setValue(fv, raw, field.Tag); err != nil {
+			b.errs = append(b.errs, errs.WrapMsg(err, "parse environment variable failed", "name", key, "field", field.Name))
+		}
+	}
+}
+
+// bindPtr 绑定为 nil 的结构体指针 v，只有嵌套结构体中有环境变量被设置时才分配并保留其错误。
+func (b *binder) bindPtr(v reflect.Value, prefix string) {
+	sub := &binder{visiting: b.visiting}
+	elem := reflect.New(v.Type().Elem())
+	sub.bindStruct(elem.Elem(), prefix)
+	if !sub.matched {
+		return
+	}
+	v.Set(elem)
+	b.matched = true
+	b.errs = append(b.errs, sub.errs...)
+}
+
+func hasOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isNested 判断 t 是否为需要递归绑定的结构体。
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && t != urlType && !isTextUnmarshaler(t)
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setValue 将 raw 解析后写入 v，切片和 map 按 sep 标签拆分。
+func setValue(v reflect.Value, raw string, tag reflect.StructTag) error {
+	if v.Kind() == reflect.Ptr && !v.Type().Implements(textUnmarshalerType) {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), raw, tag); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if isTextUnmarshaler(v.Type()) && v.Type() != timeType {
+		return setScalar(v, raw, tag)
+	}
+	sep := tag.Get(SepTagName)
+	if sep == "" {
+		sep = ","
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(raw))
+			return nil
+		}
+		var parts []string
+		if raw != "" {
+			parts = strings.Split(raw, sep)
+		}
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setScalar(s.Index(i), strings.TrimSpace(part), tag); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		if raw != "" {
+			for _, part := range strings.Split(raw, sep) {
+				k, val, ok := strings.Cut(part, "=")
+				if !ok {
+					return errs.New("invalid map entry", "entry", part).Wrap()
+				}
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := setScalar(key, strings.TrimSpace(k), tag); err != nil {
+					return err
+				}
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := setScalar(elem, strings.TrimSpace(val), tag); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, elem)
+			}
+		}
+		v.Set(m)
+		return nil
+	default:
+		return setScalar(v, raw, tag)
+	}
+}
+
+func setScalar(v reflect.Value, raw string, tag reflect.StructTag) error {
+	switch {
+	case v.Kind() == reflect.Ptr && !v.Type().Implements(textUnmarshalerType):
+		elem := reflect.New(v.Type().Elem())
+		if err := setScalar(elem.Elem(), raw, tag); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case v.Type() == timeType:
+		layout := tag.Get(LayoutTagName)
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == urlType:
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	case v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return errs.New("unsupported type", "type", v.Type()).Wrap()
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type bindConfig struct {
+	Port    int            `env:"PORT,required"`
+	Debug   bool           `env:"DEBUG"`
+	Timeout time.Duration  `env:"TIMEOUT" default:"5s"`
+	Start   time.Time      `env:"START" layout:"2006-01-02"`
+	Hosts   []string       `env:"HOSTS" sep:";"`
+	Ports   []uint16       `env:"PORTS"`
+	Labels  map[string]int `env:"LABELS"`
+	Level   level          `env:"LEVEL" default:"info"`
+	IP      net.IP         `env:"IP"`
+	Ratio   *float64       `env:"RATIO"`
+	Ignored string         `env:"-"`
+	Skipped string
+	Mongo   struct {
+		URI      *url.URL `env:"URI"`
+		Database string   `env:"DATABASE" default:"openim"`
+	} `env:"MONGO"`
+	Redis *struct {
+		Addr string `env:"REDIS_ADDR"`
+	}
+}
+
+func TestBind(t *testing.T) {
+	t.Setenv("APP_PORT", "10001")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_START", "2024-05-08")
+	t.Setenv("APP_HOSTS", "a; b")
+	t.Setenv("APP_PORTS", "1,2")
+	t.Setenv("APP_LABELS", "x=1,y=2")
+	t.Setenv("APP_LEVEL", "debug")
+	t.Setenv("APP_IP", "127.0.0.1")
+	t.Setenv("APP_RATIO", "0.5")
+	t.Setenv("APP_MONGO_URI", "mongodb://a:27017")
+	t.Setenv("APP_REDIS_ADDR", "b:6379")
+	t.Setenv("APP_SKIPPED", "x")
+
+	var cfg bindConfig
+	assert.NoError(t, Bind(&cfg, "APP"))
+	assert.Equal(t, 10001, cfg.Port)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC), cfg.Start)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, []uint16{1, 2}, cfg.Ports)
+	assert.Equal(t, map[string]int{"x": 1, "y": 2}, cfg.Labels)
+	assert.Equal(t, level(1), cfg.Level)
+	assert.Equal(t, "127.0.0.1", cfg.IP.String())
+	assert.Equal(t, 0.5, *cfg.Ratio)
+	assert.Equal(t, "a:27017", cfg.Mongo.URI.Host)
+	assert.Equal(t, "openim", cfg.Mongo.Database)
+	assert.Equal(t, "b:6379", cfg.Redis.Addr)
+	assert.Empty(t, cfg.Skipped)
+}
+
+func TestBindErrors(t *testing.T) {
+	t.Setenv("APP_TIMEOUT", "soon")
+	t.Setenv("APP_LEVEL", "trace")
+	t.Setenv("APP_LABELS", "x")
+
+	var cfg bindConfig
+	err := Bind(&cfg, "APP")
+	var bindErrs *BindErrors
+	assert.True(t, errors.As(err, &bindErrs))
+	assert.Len(t, bindErrs.Errors, 4)
+	assert.ErrorContains(t, err, "name=APP_PORT")
+	assert.ErrorContains(t, err, "name=APP_TIMEOUT")
+	assert.ErrorContains(t, err, "name=APP_LEVEL")
+	assert.ErrorContains(t, err, "name=APP_LABELS")
+
+	assert.Error(t, Bind(cfg, "APP"))
+}
+
+type bindNode struct {
+	Name string    `env:"NAME"`
+	Next *bindNode `env:"NEXT"`
+}
+
+type bindOptional struct {
+	Name  string `env:"NAME"`
+	Redis *struct {
+		Addr string `env:"ADDR,required"`
+		DB   int    `env:"DB" default:"1"`
+	} `env:"REDIS"`
+	Node *bindNode `env:"NODE"`
+}
+
+func TestBindNilPointers(t *testing.T) {
+	t.Setenv("OPT_NAME", "a")
+	var cfg bindOptional
+	assert.NoError(t, Bind(&cfg, "OPT"))
+	assert.Nil(t, cfg.Redis)
+	assert.Nil(t, cfg.Node)
+
+	// A section partially set reports its missing required fields, an allocated one always does.
+	t.Setenv("OPT_REDIS_DB", "2")
+	assert.ErrorContains(t, Bind(&cfg, "OPT"), "OPT_REDIS_ADDR")
+	cfg.Redis = nil
+	assert.NoError(t, os.Unsetenv("OPT_REDIS_DB"))
+	var allocated bindOptional
+	allocated.Redis = &struct {
+		Addr string `env:"ADDR,required"`
+		DB   int    `env:"DB" default:"1"`
+	}{}
+	assert.ErrorContains(t, Bind(&allocated, "OPT"), "OPT_REDIS_ADDR")
+
+	t.Setenv("OPT_REDIS_ADDR", "b:6379")
+	t.Setenv("OPT_NODE_NAME", "n1")
+	t.Setenv("OPT_NODE_NEXT_NAME", "n2")
+	assert.NoError(t, Bind(&cfg, "OPT"))
+	if assert.NotNil(t, cfg.Redis) {
+		assert.Equal(t, "b:6379", cfg.Redis.Addr)
+		assert.Equal(t, 1, cfg.Redis.DB)
+	}
+	if assert.NotNil(t, cfg.Node) {
+		assert.Equal(t, "n1", cfg.Node.Name)
+		assert.Nil(t, cfg.Node.Next)
+	}
+
+	var node bindNode
+	t.Setenv("NODE_NAME", "root")
+	assert.NoError(t, Bind(&node, "NODE"))
+	assert.Equal(t, "root", node.Name)
+	assert.Nil(t, node.Next)
+}
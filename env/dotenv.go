@@ -0,0 +1,309 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Meikwei/go-tools/errs"
+)
+
+// DefaultFiles 是未指定文件时按顺序加载的 .env 文件，后面的文件覆盖前面的。
+var DefaultFiles = []string{".env", ".env.local"}
+
+var (
+	lookupLock sync.RWMutex
+	lookupVars map[string]string
+)
+
+// lookup 查找环境变量，进程环境变量优先，其次是 LoadLookup 加载的值。
+func lookup(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, true
+	}
+	lookupLock.RLock()
+	defer lookupLock.RUnlock()
+	v, ok := lookupVars[key]
+	return v, ok
+}
+
+// Load 读取 .env 文件并写入进程环境变量，已存在的变量不会被覆盖。
+// 不存在的文件会被跳过，未指定文件时加载 DefaultFiles。
+func Load(files ...string) error {
+	return load(false, files)
+}
+
+// Overload 与 Load 相同，但会覆盖已存在的环境变量。
+func Overload(files ...string) error {
+	return load(true, files)
+}
+
+func load(override bool, files []string) error {
+	vars, err := read(files, override)
+	if err != nil {
+		return err
+	}
+	for k, v := range vars {
+		if _, ok := os.LookupEnv(k); ok && !override {
+			continue
+		}
+		if err := os.Setenv(k, v); err != nil {
+			return errs.WrapMsg(err, "Setenv failed", "key", k)
+		}
+	}
+	return nil
+}
+
+// LoadLookup 读取 .env 文件但不修改进程环境变量，加载的值供 GetString、GetInt、
+// GetFloat64、GetBool 和 Bind 在进程环境变量未设置时使用。
+func LoadLookup(files ...string) error {
+	vars, err := read(files, false)
+	if err != nil {
+		return err
+	}
+	lookupLock.Lock()
+	defer lookupLock.Unlock()
+	if lookupVars == nil {
+		lookupVars = make(map[string]string, len(vars))
+	}
+	for k, v := range vars {
+		lookupVars[k] = v
+	}
+	return nil
+}
+
+// Read 按顺序读取并合并 .env 文件，后面的文件覆盖前面的，后面的文件可以引用前面文件中的变量。
+// 不存在的文件会被跳过，未指定文件时读取 DefaultFiles。插值与 Load 相同，进程环境变量优先。
+func Read(files ...string) (map[string]string, error) {
+	return read(files, false)
+}
+
+// read 读取并合并 files，override 表示插值时文件中的值优先于进程环境变量，与 Overload 一致。
+func read(files []string, override bool) (map[string]string, error) {
+	if len(files) == 0 {
+		files = DefaultFiles
+	}
+	vars := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errs.WrapMsg(err, "ReadFile failed", "file", file)
+		}
+		if err := parse(string(data), vars, override); err != nil {
+			return nil, errs.WrapMsg(err, "parse env file failed", "file", file)
+		}
+	}
+	return vars, nil
+}
+
+// Parse 解析 .env 格式的内容：
+//
+//	# 注释
+//	export NAME=value           # 行尾注释
+//	QUOTED="a \"quoted\" value\n"
+//	RAW='不做转义和插值的值'
+//	MULTILINE="第一行
+//	第二行"
+//	URL=mongodb://${MONGO_HOST:-localhost}:$MONGO_PORT
+//
+// 双引号和无引号的值支持 ${VAR}、$VAR 和 ${VAR:-default} 插值，default 中可以嵌套插值，
+// \$ 表示字面的 $。变量取加载后的有效值：与 Load 相同，进程环境变量优先于已解析的值。
+func Parse(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	if err := parse(string(data), vars, false); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func parse(s string, vars map[string]string, override bool) error {
+	e := expander{vars: vars, override: override}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	line := 1
+	for len(s) > 0 {
+		var current string
+		current, s, _ = strings.Cut(s, "\n")
+		start := line
+		line++
+		trimmed := strings.TrimSpace(current)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(trimmed, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			trimmed = strings.TrimLeft(rest, " \t")
+		}
+		key, value, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validKey(key) {
+			return errs.New("invalid env line", "line", start).Wrap()
+		}
+		value = strings.TrimLeft(value, " \t")
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			quote := value[0]
+			// 引号内的值可以跨行，读到未转义的结束引号为止
+			for {
+				end := closingQuote(value, quote)
+				if end >= 0 {
+					if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+						return errs.New("unexpected characters after quoted value", "line", start).Wrap()
+					}
+					value = value[1:end]
+					break
+				}
+				if s == "" {
+					return errs.New("unterminated quoted value", "line", start).Wrap()
+				}
+				var next string
+				next, s, _ = strings.Cut(s, "\n")
+				line++
+				value += "\n" + next
+			}
+			if quote == '\'' {
+				vars[key] = value
+			} else {
+				vars[key] = e.expand(value, true)
+			}
+			continue
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		vars[key] = e.expand(strings.TrimRight(value, " \t"), false)
+	}
+	return nil
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if c == '_' || c == '.' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// closingQuote 返回 value 中结束引号的位置，value[0] 是开始引号。
+func closingQuote(value string, quote byte) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// closingBrace 返回 s 中与 start 之前的 "${" 匹配的 "}" 的位置，跳过嵌套的 ${...} 和转义字符。
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expander 在一次扫描中处理值的转义和插值。
+type expander struct {
+	vars     map[string]string
+	override bool
+}
+
+// expand 替换 s 中的 ${VAR}、$VAR 和 ${VAR:-default}。escapes 为 true 时处理双引号值的
+// \n、\t、\r 等转义，否则只处理 \$。
+func (e expander) expand(s string, escapes bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (escapes || s[i+1] == '$'):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			name, def, hasDefault := strings.Cut(s[i+2:end], ":-")
+			v, ok := e.lookup(name)
+			if (!ok || v == "") && hasDefault {
+				v = e.expand(def, escapes)
+			}
+			b.WriteString(v)
+			i = end
+		case s[i] == '$':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || (s[j] >= 'A' && s[j] <= 'Z') || (s[j] >= 'a' && s[j] <= 'z') || (j > i+1 && s[j] >= '0' && s[j] <= '9')) {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte('$')
+				continue
+			}
+			v, _ := e.lookup(s[i+1 : j])
+			b.WriteString(v)
+			i = j - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// lookup 返回 name 加载后的有效值：Overload 时文件中的值优先，否则进程环境变量优先，
+// 最后是 LoadLookup 加载的值。
+func (e expander) lookup(name string) (string, bool) {
+	if !e.override {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+	}
+	if v, ok := e.vars[name]; ok {
+		return v, true
+	}
+	return lookup(name)
+}
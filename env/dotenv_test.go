@@ -0,0 +1,158 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unsetenv unsets keys for the test and restores them afterwards.
+func unsetenv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+		assert.NoError(t, os.Unsetenv(key))
+	}
+}
+
+func writeEnvFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func TestParse(t *testing.T) {
+	unsetenv(t, "DOTENV_UNSET", "DOTENV_ALSO_UNSET")
+	t.Setenv("DOTENV_HOME", "/home/im")
+	vars, err := Parse([]byte(`# comment
+  # indented comment
+
+export PLAIN=value   # trailing comment
+export	TABBED=tab
+exporter=not an export
+SPACED = spaced value
+HASH=a#b
+SINGLE='raw $DOTENV_HOME \n ${X}' # comment
+DOUBLE="a \"quoted\" value\n\tend"
+MULTI="first
+second"
+MULTI_RAW='line1
+line2'
+BRACED=${DOTENV_HOME}/data
+BARE=$DOTENV_HOME/bin
+REF=${PLAIN}-$SPACED
+DEFAULT=${DOTENV_UNSET:-fallback}
+EMPTY=
+EMPTY_DEFAULT=${EMPTY:-used}
+NESTED=${DOTENV_UNSET:-${PLAIN}}
+DEEP=${DOTENV_UNSET:-${DOTENV_ALSO_UNSET:-deep}}/x
+LITERAL=\$DOTENV_HOME
+QUOTED_LITERAL="\$DOTENV_HOME"
+BACKSLASH="\\$DOTENV_HOME"
+UNKNOWN=[$DOTENV_UNSET]
+DOLLAR=cost $ 5
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":          "value",
+		"TABBED":         "tab",
+		"exporter":       "not an export",
+		"SPACED":         "spaced value",
+		"HASH":           "a#b",
+		"SINGLE":         `raw $DOTENV_HOME \n ${X}`,
+		"DOUBLE":         "a \"quoted\" value\n\tend",
+		"MULTI":          "first\nsecond",
+		"MULTI_RAW":      "line1\nline2",
+		"BRACED":         "/home/im/data",
+		"BARE":           "/home/im/bin",
+		"REF":            "value-spaced value",
+		"DEFAULT":        "fallback",
+		"EMPTY":          "",
+		"EMPTY_DEFAULT":  "used",
+		"NESTED":         "value",
+		"DEEP":           "deep/x",
+		"LITERAL":        "$DOTENV_HOME",
+		"QUOTED_LITERAL": "$DOTENV_HOME",
+		"BACKSLASH":      `\/home/im`,
+		"UNKNOWN":        "[]",
+		"DOLLAR":         "cost $ 5",
+	}, vars)
+
+	for _, data := range []string{"NO_EQUALS\n", "1KEY=x\n", "KEY=\"unterminated\n", "KEY='a' b\n"} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestParseEnvPrecedence(t *testing.T) {
+	t.Setenv("DOTENV_HOST", "env-host")
+	vars, err := Parse([]byte("DOTENV_HOST=file-host\nURL=http://${DOTENV_HOST}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "file-host", vars["DOTENV_HOST"])
+	assert.Equal(t, "http://env-host", vars["URL"])
+}
+
+func TestLoad(t *testing.T) {
+	file := writeEnvFile(t, ".env", "DOTENV_HOST=file-host\nDOTENV_URL=http://${DOTENV_HOST}:$DOTENV_PORT\nDOTENV_PORT=80\n")
+	local := writeEnvFile(t, ".env.local", "DOTENV_PORT=8080\n")
+
+	unsetenv(t, "DOTENV_URL", "DOTENV_PORT")
+	t.Setenv("DOTENV_HOST", "env-host")
+	assert.NoError(t, Load(file, local, filepath.Join(t.TempDir(), "missing.env")))
+	assert.Equal(t, "env-host", os.Getenv("DOTENV_HOST"))
+	assert.Equal(t, "http://env-host:", os.Getenv("DOTENV_URL"))
+	assert.Equal(t, "8080", os.Getenv("DOTENV_PORT"))
+
+	unsetenv(t, "DOTENV_URL", "DOTENV_PORT")
+	t.Setenv("DOTENV_HOST", "env-host")
+	assert.NoError(t, Overload(file))
+	assert.Equal(t, "file-host", os.Getenv("DOTENV_HOST"))
+	assert.Equal(t, "http://file-host:", os.Getenv("DOTENV_URL"))
+	assert.Equal(t, "80", os.Getenv("DOTENV_PORT"))
+
+	assert.Error(t, Load(writeEnvFile(t, "bad.env", "not a line\n")))
+}
+
+func TestLoadLookup(t *testing.T) {
+	t.Cleanup(func() {
+		lookupLock.Lock()
+		lookupVars = nil
+		lookupLock.Unlock()
+	})
+	unsetenv(t, "DOTENV_NAME", "DOTENV_PORT", "DOTENV_ADDR")
+	t.Setenv("DOTENV_HOST", "env-host")
+	file := writeEnvFile(t, ".env", "DOTENV_NAME=file-name\nDOTENV_HOST=file-host\nDOTENV_PORT=10001\nDOTENV_ADDR=${DOTENV_HOST}:${DOTENV_PORT}\n")
+	assert.NoError(t, LoadLookup(file))
+
+	_, ok := os.LookupEnv("DOTENV_NAME")
+	assert.False(t, ok)
+	assert.Equal(t, "file-name", GetString("DOTENV_NAME", ""))
+	assert.Equal(t, "env-host", GetString("DOTENV_HOST", ""))
+	assert.Equal(t, "env-host:10001", GetString("DOTENV_ADDR", ""))
+	port, err := GetInt("DOTENV_PORT", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 10001, port)
+
+	var cfg struct {
+		Name string `env:"NAME"`
+		Port int    `env:"PORT"`
+	}
+	assert.NoError(t, Bind(&cfg, "DOTENV"))
+	assert.Equal(t, "file-name", cfg.Name)
+	assert.Equal(t, 10001, cfg.Port)
+}
@@ -23,7 +23,6 @@
 package env
 
 import (
-	"os"
 	"strconv"
 
 	"github.com/Meikwei/go-tools/errs"
@@ -33,7 +32,7 @@ import (
 // 如果该键未设置，则返回提供的默认值。
 func GetString(key, defaultValue string) string {
 	// 查找与键关联的环境变量
-	v, ok := os.LookupEnv(key)
+	v, ok := lookup(key)
 	if ok {
 		// 如果找到键，则返回其值
 		return v
@@ -45,7 +44,7 @@ func GetString(key, defaultValue string) string {
 // GetInt 返回环境变量解析为整数的值，或在未设置时返回默认值。
 // 它将与键关联的值解析为整数。
 func GetInt(key string, defaultValue int) (int, error) {
-	v, ok := os.LookupEnv(key)
+	v, ok := lookup(key)
 	if ok {
 		// 尝试将环境变量值转换为整数
 		value, err := strconv.Atoi(v)
@@ -62,7 +61,7 @@ func GetInt(key string, defaultValue int) (int, error) {
 // GetFloat64 返回环境变量解析为浮点数的值，或在未设置时返回默认值。
 // 它将与键关联的值解析为64位浮点数。
 func GetFloat64(key string, defaultValue float64) (float64, error) {
-	v, ok := os.LookupEnv(key)
+	v, ok := lookup(key)
 	if ok {
 		// 尝试将环境变量值转换为浮点数
 		value, err := strconv.ParseFloat(v, 64)
@@ -79,7 +78,7 @@ func GetFloat64(key string, defaultValue float64) (float64, error) {
 // GetBool 返回环境变量解析为布尔值的值，或在未设置时返回默认值。
 // 它将与键关联的值解析为布尔值。
 func GetBool(key string, defaultValue bool) (bool, error) {
-	v, ok := os.LookupEnv(key)
+	v, ok := lookup(key)
 	if ok {
 		// 尝试将环境变量值转换为布尔值
 		value, err := strconv.ParseBool(v)
@@ -128,7 +128,10 @@ func WrapMsg(err error, msg string, kv ...any) error {
     if err == nil {
         return nil
     }
-    withMessage := errors.WithMessage(err, toString(msg, kv))
+    if len(kv) > 0 {
+        return errors.WithStack(&withFields{cause: err, msg: msg, kv: kv})
+    }
+    withMessage := errors.WithMessage(err, msg)
     return errors.WithStack(withMessage)
 }
 
@@ -35,13 +35,15 @@ type Error interface {
 // New 创建并返回一个新的Error实例，允许错误消息和键值对的传递。
 func New(s string, kv ...any) Error {
 	return &errorString{
-		s: toString(s, kv),
+		s:  toString(s, kv),
+		kv: kv,
 	}
 }
 
 // errorString 是一个实现了Error接口的错误类型。
 type errorString struct {
-	s string
+	s  string
+	kv []any
 }
 
 // Is 检查当前错误是否与另一个错误相等。
@@ -58,6 +60,11 @@ func (e *errorString) Error() string {
 	return e.s
 }
 
+// KeyValues 返回创建错误时传入的键值对。
+func (e *errorString) KeyValues() []any {
+	return e.kv
+}
+
 // Wrap 返回当前错误的一个封装，允许错误链的构建。
 func (e *errorString) Wrap() error {
 	return Wrap(e)
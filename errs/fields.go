@@ -0,0 +1,78 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+import (
+	"fmt"
+	"io"
+)
+
+// withFields 是 WrapMsg 添加的带键值对的消息，Error 的格式与 errors.WithMessage 相同。
+type withFields struct {
+	cause error
+	msg   string
+	kv    []any
+}
+
+func (w *withFields) Error() string {
+	return toString(w.msg, w.kv) + ": " + w.cause.Error()
+}
+
+// KeyValues 返回 WrapMsg 传入的键值对。
+func (w *withFields) KeyValues() []any {
+	return w.kv
+}
+
+func (w *withFields) Cause() error {
+	return w.cause
+}
+
+func (w *withFields) Unwrap() error {
+	return w.cause
+}
+
+// Format 与 errors.WithMessage 的格式一致，%+v 输出被包装错误的堆栈。
+func (w *withFields) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v\n", w.Cause())
+			io.WriteString(s, toString(w.msg, w.kv))
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// Fields 返回错误链中所有错误携带的键值对，外层在前。New、WrapMsg 和 FromStatus
+// 产生的错误会保留键值对，Error 仍然输出拼接后的字符串。
+func Fields(err error) []any {
+	var kv []any
+	for err != nil {
+		if e, ok := err.(interface{ KeyValues() []any }); ok {
+			kv = append(kv, e.KeyValues()...)
+		}
+		unwrap, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = unwrap.Unwrap()
+	}
+	return kv
+}
@@ -0,0 +1,46 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	err := New("user not found", "userID", "u1").Wrap()
+	assert.Equal(t, "user not found, userID=u1", err.Error())
+	assert.Equal(t, []any{"userID", "u1"}, Fields(err))
+
+	err = WrapMsg(err, "get group owner failed", "groupID", "g1", "count", 2)
+	assert.Equal(t, "get group owner failed, groupID=g1, count=2: user not found, userID=u1", err.Error())
+	assert.Equal(t, []any{"groupID", "g1", "count", 2, "userID", "u1"}, Fields(err))
+	assert.Contains(t, fmt.Sprintf("%+v", err), "get group owner failed, groupID=g1, count=2")
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+
+	err = ErrArgs.WrapMsg("check failed", "field", "name")
+	assert.Equal(t, "check failed, field=name: 1001 ArgsError", err.Error())
+	assert.True(t, ErrArgs.Is(err))
+	var codeErr CodeError
+	assert.True(t, errors.As(err, &codeErr))
+	assert.Equal(t, []any{"field", "name"}, Fields(err))
+
+	assert.Equal(t, "plain: boom", WrapMsg(errors.New("boom"), "plain").Error())
+	assert.Empty(t, Fields(errors.New("boom")))
+	assert.Nil(t, Fields(nil))
+}
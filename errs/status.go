@@ -0,0 +1,229 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusReason 是 ToStatus 写入的 errdetails.ErrorInfo 的 Reason，用于识别本包编码的错误。
+const StatusReason = "CODE_ERROR"
+
+// ErrorInfo.Metadata 中的键，kv 是键值对的 JSON 数组，值保留 JSON 类型。
+const (
+	statusKeyCode   = "code"
+	statusKeyMsg    = "msg"
+	statusKeyDetail = "detail"
+	statusKeyCause  = "cause"
+	statusKeyKV     = "kv"
+)
+
+// grpcCodes 是业务错误码对应的标准 gRPC 错误码，未列出的错误码对应 codes.Unknown。
+var grpcCodes = map[int]codes.Code{
+	ServerInternalError:   codes.Internal,
+	ArgsError:             codes.InvalidArgument,
+	NoPermissionError:     codes.PermissionDenied,
+	DuplicateKeyError:     codes.AlreadyExists,
+	RecordNotFoundError:   codes.NotFound,
+	TokenExpiredError:     codes.Unauthenticated,
+	TokenMalformedError:   codes.Unauthenticated,
+	TokenNotValidYetError: codes.Unauthenticated,
+	TokenUnknownError:     codes.Unauthenticated,
+}
+
+// GRPCCode 返回业务错误码对应的标准 gRPC 错误码。
+func GRPCCode(code int) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	return codes.Unknown
+}
+
+// ToStatus 将 err 编码为 gRPC status：标准 gRPC 错误码，以及携带业务错误码、msg、detail、
+// 完整错误链、键值对和来源服务的 errdetails.ErrorInfo。错误链中没有 CodeError 时按
+// ErrInternalServer 编码。已经来自其他服务的错误保留其原始来源服务，service 只用于本服务产生的错误。
+//
+// 注意 status 的错误码是标准 gRPC 错误码而不是业务错误码，按旧约定以
+// NewCodeError(int(sta.Code())) 解码的旧客户端会得到错误的错误码，需要先升级为使用 FromStatus。
+func ToStatus(err error, service string) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	var codeErr CodeError
+	if !errors.As(err, &codeErr) {
+		codeErr = ErrInternalServer
+	}
+	code := codeErr.Code()
+	if code <= 0 {
+		code = ServerInternalError
+	}
+	var remote *StatusError
+	if errors.As(err, &remote) && remote.service != "" {
+		service = remote.service
+	}
+	metadata := map[string]string{
+		statusKeyCode:  strconv.Itoa(code),
+		statusKeyMsg:   codeErr.Msg(),
+		statusKeyCause: err.Error(),
+	}
+	if detail := codeErr.Detail(); detail != "" {
+		metadata[statusKeyDetail] = detail
+	}
+	if kv := Fields(err); len(kv) > 0 {
+		metadata[statusKeyKV] = encodeKV(kv)
+	}
+	st := status.New(GRPCCode(code), codeErr.Msg())
+	details, detailErr := st.WithDetails(&errdetails.ErrorInfo{Reason: StatusReason, Domain: service, Metadata: metadata})
+	if detailErr != nil {
+		return st
+	}
+	return details
+}
+
+// FromStatus 解码 ToStatus 编码的 status，返回的错误链底层是 *StatusError。
+// 不是 ToStatus 编码的 status 按旧的约定解码，gRPC 错误码即业务错误码。st 为 nil 或 OK 时返回 nil。
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Reason != StatusReason {
+			continue
+		}
+		md := info.Metadata
+		code, err := strconv.Atoi(md[statusKeyCode])
+		if err != nil {
+			continue
+		}
+		e := &StatusError{
+			codeError: codeError{code: code, msg: md[statusKeyMsg], detail: md[statusKeyDetail]},
+			service:   info.Domain,
+			cause:     md[statusKeyCause],
+		}
+		if kv := md[statusKeyKV]; kv != "" {
+			e.kv = decodeKV(kv)
+		}
+		return Wrap(e)
+	}
+	return NewCodeError(int(st.Code()), st.Message()).Wrap()
+}
+
+// encodeKV 将键值对编码为 JSON 数组。键编码为字符串，值保留其 JSON 类型，error 和 fmt.Stringer
+// 编码为字符串，无法编码为 JSON 的值按 fmt.Sprint 编码。
+func encodeKV(kv []any) string {
+	values := make([]json.RawMessage, len(kv))
+	for i, v := range kv {
+		if i%2 == 0 {
+			v = fmt.Sprint(v)
+		} else {
+			switch val := v.(type) {
+			case error:
+				v = val.Error()
+			case fmt.Stringer:
+				v = val.String()
+			}
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprint(v))
+		}
+		values[i] = data
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// decodeKV 解码 encodeKV 编码的键值对，整数解码为 int64，其他数字解码为 float64。
+func decodeKV(s string) []any {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil
+	}
+	for i, v := range values {
+		values[i] = jsonNumbers(v)
+	}
+	return values
+}
+
+// jsonNumbers 将 v 中的 json.Number 转换为 int64 或 float64。
+func jsonNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case []any:
+		for i, child := range val {
+			val[i] = jsonNumbers(child)
+		}
+	case map[string]any:
+		for k, child := range val {
+			val[k] = jsonNumbers(child)
+		}
+	}
+	return v
+}
+
+// StatusError 是从其他服务的 gRPC status 解码的 CodeError，Error 返回原服务的完整错误链。
+type StatusError struct {
+	codeError
+	kv      []any
+	service string
+	cause   string
+}
+
+// Service 返回产生该错误的服务。
+func (e *StatusError) Service() string {
+	return e.service
+}
+
+// KeyValues 返回原服务错误链中的键值对。
+func (e *StatusError) KeyValues() []any {
+	return e.kv
+}
+
+func (e *StatusError) WithDetail(detail string) CodeError {
+	c := *e
+	c.codeError = *e.codeError.WithDetail(detail).(*codeError)
+	return &c
+}
+
+func (e *StatusError) Wrap() error {
+	return Wrap(e)
+}
+
+func (e *StatusError) WrapMsg(msg string, kv ...any) error {
+	return WrapMsg(e, msg, kv...)
+}
+
+func (e *StatusError) Error() string {
+	if e.cause != "" {
+		return e.cause
+	}
+	return e.codeError.Error()
+}
@@ -0,0 +1,77 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	origin := ErrRecordNotFound.WithDetail("user").WrapMsg("get user failed", "userID", 42, "ratio", 0.5, "active", true,
+		"tags", []string{"a", "b"}, "timeout", time.Second, "cause", errors.New("no rows"), "none", nil)
+
+	// 第一跳：user 服务返回错误
+	st := ToStatus(origin, "openim.user.user")
+	assert.Equal(t, codes.NotFound, st.Code())
+	err := FromStatus(status.Convert(st.Err()))
+
+	var codeErr CodeError
+	assert.True(t, errors.As(err, &codeErr))
+	assert.Equal(t, RecordNotFoundError, codeErr.Code())
+	assert.Equal(t, ErrRecordNotFound.Msg(), codeErr.Msg())
+	assert.Equal(t, "user", codeErr.Detail())
+	assert.Equal(t, origin.Error(), err.Error())
+	assert.True(t, ErrRecordNotFound.Is(err))
+
+	var remote *StatusError
+	assert.True(t, errors.As(err, &remote))
+	assert.Equal(t, "openim.user.user", remote.Service())
+	userKV := []any{"userID", int64(42), "ratio", 0.5, "active", true,
+		"tags", []any{"a", "b"}, "timeout", "1s", "cause", "no rows", "none", nil}
+	assert.Equal(t, userKV, remote.KeyValues())
+
+	// 第二跳：group 服务包装后继续返回
+	wrapped := WrapMsg(err, "get group owner failed", "groupID", "g1")
+	err = FromStatus(status.Convert(ToStatus(wrapped, "openim.group.group").Err()))
+	assert.True(t, errors.As(err, &remote))
+	assert.Equal(t, RecordNotFoundError, remote.Code())
+	assert.Equal(t, "user", remote.Detail())
+	assert.Equal(t, "openim.user.user", remote.Service())
+	assert.Equal(t, append([]any{"groupID", "g1"}, userKV...), remote.KeyValues())
+	assert.Equal(t, wrapped.Error(), err.Error())
+	assert.Contains(t, err.Error(), "get group owner failed")
+	assert.Contains(t, err.Error(), "get user failed")
+}
+
+func TestStatusPlainError(t *testing.T) {
+	st := ToStatus(errors.New("boom"), "svc")
+	assert.Equal(t, codes.Internal, st.Code())
+	err := FromStatus(st)
+	assert.True(t, ErrInternalServer.Is(err))
+	assert.Equal(t, "boom", err.Error())
+
+	assert.Nil(t, FromStatus(nil))
+	assert.Nil(t, FromStatus(status.New(codes.OK, "")))
+
+	// 旧格式：gRPC 错误码即业务错误码
+	err = FromStatus(status.New(codes.Code(ArgsError), "bad args"))
+	assert.True(t, ErrArgs.Is(err))
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/Meikwei/go-tools/utils/stringutil"
 
+	"github.com/Meikwei/go-tools/errs"
 	"github.com/Meikwei/go-tools/mcontext"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"github.com/openimsdk/protocol/constant"
@@ -343,6 +344,7 @@ func (l *ZapLogger) Warn(ctx context.Context, msg string, err error, keysAndValu
 	}
 	if err != nil {
 		keysAndValues = append(keysAndValues, "error", err.Error())
+		keysAndValues = appendErrorFields(keysAndValues, err)
 	}
 	keysAndValues = l.kvAppend(ctx, keysAndValues)
 	l.zap.Warnw(msg, keysAndValues...)
@@ -354,11 +356,34 @@ func (l *ZapLogger) Error(ctx context.Context, msg string, err error, keysAndVal
 	}
 	if err != nil {
 		keysAndValues = append(keysAndValues, "error", err.Error())
+		keysAndValues = appendErrorFields(keysAndValues, err)
 	}
 	keysAndValues = l.kvAppend(ctx, keysAndValues)
 	l.zap.Errorw(msg, keysAndValues...)
 }
 
+// appendErrorFields appends the key-values carried by the chain of err, see errs.Fields, as
+// individual fields. Keys already logged by the caller are kept.
+func appendErrorFields(keysAndValues []any, err error) []any {
+	fields := errs.Fields(err)
+	if len(fields) == 0 {
+		return keysAndValues
+	}
+	logged := make(map[string]struct{}, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		logged[fmt.Sprint(keysAndValues[i])] = struct{}{}
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		if _, ok := logged[key]; ok {
+			continue
+		}
+		logged[key] = struct{}{}
+		keysAndValues = append(keysAndValues, key, fields[i+1])
+	}
+	return keysAndValues
+}
+
 func (l *ZapLogger) kvAppend(ctx context.Context, keysAndValues []any) []any {
 	if ctx == nil {
 		return keysAndValues
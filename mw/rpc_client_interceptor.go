@@ -80,15 +80,15 @@ func RpcClientInterceptor(ctx context.Context, method string, req, resp any, cc
 		return errs.NewCodeError(errs.ServerInternalError, err.Error()).Wrap()
 	}
 
-	// 处理错误详情，如果有
-	if details := sta.Details(); len(details) > 0 {
-		errInfo, ok := details[0].(*errinfo.ErrorInfo)
-		if ok {
+	// 兼容旧服务端：错误详情为 errinfo.ErrorInfo 时按旧格式解码
+	for _, detail := range sta.Details() {
+		if errInfo, ok := detail.(*errinfo.ErrorInfo); ok {
 			s := strings.Join(errInfo.Warp, "->") + errInfo.Cause
 			return errs.NewCodeError(int(sta.Code()), sta.Message()).WithDetail(s).Wrap()
 		}
 	}
-	return errs.NewCodeError(int(sta.Code()), sta.Message()).Wrap()
+	// 解码服务端 errs.ToStatus 编码的错误
+	return errs.FromStatus(sta)
 }
 
 // getRpcContext 生成或更新上下文，添加自定义的头部信息和一些关键的上下文变量。
@@ -17,7 +17,6 @@ package mw
 import (
 	"context"
 	"fmt"
-	"math"
 	"runtime"
 	"strings"
 
@@ -28,7 +27,6 @@ import (
 	"github.com/Meikwei/go-tools/log"
 	"github.com/Meikwei/go-tools/mw/specialerror"
 	"github.com/openimsdk/protocol/constant"
-	"github.com/openimsdk/protocol/errinfo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -53,8 +51,8 @@ func RpcServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerIn
 		return nil, err
 	}
 	log.ZInfo(ctx, fmt.Sprintf("RPC Server Request - %s", extractFunctionName(funcName)), "funcName", funcName, "req", rpcString(req))
-	if err := checker.Validate(req); err != nil {
-		return nil, err
+	if err := checker.Validate(ctx, req); err != nil {
+		return nil, handleError(ctx, funcName, req, err)
 	}
 
 	resp, err := handler(ctx, req)
@@ -102,26 +100,42 @@ func enrichContextWithMetadata(ctx context.Context, md metadata.MD) (context.Con
 
 func handleError(ctx context.Context, funcName string, req any, err error) error {
 	log.ZWarn(ctx, "rpc server resp WithDetails error", formatError(err), "funcName", funcName)
-	unwrap := errs.Unwrap(err)
-	codeErr := specialerror.ErrCode(unwrap)
-	if codeErr == nil {
-		log.ZError(ctx, "rpc InternalServer error", formatError(err), "funcName", funcName, "req", req)
-		codeErr = errs.ErrInternalServer
-	}
-	code := codeErr.Code()
-	if code <= 0 || int64(code) > int64(math.MaxUint32) {
-		log.ZError(ctx, "rpc UnknownError", formatError(err), "funcName", funcName, "rpc UnknownCode:", int64(code))
-		code = errs.ServerInternalError
-	}
-	grpcStatus := status.New(codes.Code(code), err.Error())
-	errInfo := &errinfo.ErrorInfo{Cause: err.Error()}
-	details, err := grpcStatus.WithDetails(errInfo)
-	if err != nil {
-		log.ZWarn(ctx, "rpc server resp WithDetails error", formatError(err), "funcName", funcName)
-		return errs.WrapMsg(err, "rpc server resp WithDetails error", "err", err)
+	var codeErr errs.CodeError
+	if !errors.As(err, &codeErr) {
+		if codeErr = specialerror.ErrCode(errs.Unwrap(err)); codeErr != nil {
+			err = &codedError{CodeError: codeErr, cause: err}
+		} else {
+			log.ZError(ctx, "rpc InternalServer error", formatError(err), "funcName", funcName, "req", req)
+		}
 	}
-	log.ZWarn(ctx, fmt.Sprintf("RPC Server Response Error - %s", extractFunctionName(funcName)), formatError(details.Err()), "funcName", funcName, "req", req, "err", err)
-	return details.Err()
+	st := errs.ToStatus(err, serviceName(funcName))
+	log.ZWarn(ctx, fmt.Sprintf("RPC Server Response Error - %s", extractFunctionName(funcName)), formatError(st.Err()), "funcName", funcName, "req", req, "err", err)
+	return st.Err()
+}
+
+// codedError attaches the code mapped by specialerror to an error without one, keeping the
+// original error as the cause so that its message and key-values reach errs.ToStatus.
+type codedError struct {
+	errs.CodeError
+	cause error
+}
+
+func (e *codedError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.cause
+}
+
+// serviceName returns the gRPC service of a full method name, openim.user.user for
+// /openim.user.user/getUser.
+func serviceName(funcName string) string {
+	funcName = strings.TrimPrefix(funcName, "/")
+	if i := strings.LastIndex(funcName, "/"); i >= 0 {
+		return funcName[:i]
+	}
+	return funcName
 }
 
 func GrpcServer() grpc.ServerOption {
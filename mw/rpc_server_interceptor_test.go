@@ -0,0 +1,62 @@
+// Copyright © 2024 OpenIM open source community. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mw
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Meikwei/go-tools/errs"
+	"github.com/Meikwei/go-tools/mw/specialerror"
+	"github.com/openimsdk/protocol/constant"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type getUserReq struct {
+	UserID string `json:"userID" check:"required"`
+}
+
+var errUserStore = errors.New("user store unavailable")
+
+func TestRpcServerInterceptorErrors(t *testing.T) {
+	assert.NoError(t, specialerror.AddReplace(errUserStore, errs.ErrRecordNotFound))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(constant.OperationID, "op1"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/openim.user.user/getUser"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return nil, errs.WrapMsg(errUserStore, "load user failed", "userID", req.(*getUserReq).UserID)
+	}
+
+	// validation failures are encoded like handler errors
+	_, err := RpcServerInterceptor(ctx, &getUserReq{}, info, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	decoded := errs.FromStatus(st)
+	assert.True(t, errs.ErrArgs.Is(decoded))
+	assert.Contains(t, decoded.Error(), "userID")
+
+	// errors mapped by specialerror keep their message and key-values
+	_, err = RpcServerInterceptor(ctx, &getUserReq{UserID: "u1"}, info, handler)
+	st = status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	decoded = errs.FromStatus(st)
+	assert.True(t, errs.ErrRecordNotFound.Is(decoded))
+	assert.Equal(t, "load user failed, userID=u1: user store unavailable", decoded.Error())
+	assert.Equal(t, []any{"userID", "u1"}, errs.Fields(decoded))
+}
@@ -14,7 +14,9 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/md5"
+	"crypto/rand"
 	"encoding/hex"
+	"io"
 
 	"github.com/Meikwei/go-tools/errs"
 )
@@ -31,7 +33,9 @@ func Md5(s string, salt ...string) string {
 	return hex.EncodeToString(cipher)
 }
 
-// AesEncrypt encrypts the data with the key using AES encryption.
+// AesEncrypt encrypts the data with the key using AES encryption. It uses CBC with the key as
+// the IV, so equal plaintexts produce equal ciphertexts; it is kept for compatibility with the
+// data already encrypted this way, AesGCMEncrypt should be preferred for new data.
 func AesEncrypt(data []byte, key []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -52,6 +56,9 @@ func AesDecrypt(data []byte, key []byte) ([]byte, error) {
 		return nil, errs.WrapMsg(err, "NewCipher failed", "key", key)
 	}
 	blockSize := block.BlockSize()
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, errs.New("data is not a multiple of the block size", "length", len(data))
+	}
 	blockMode := cipher.NewCBCDecrypter(block, key[:blockSize])
 	crypted := make([]byte, len(data))
 	blockMode.CryptBlocks(crypted, data)
@@ -69,12 +76,64 @@ func pkcs7Padding(data []byte, blockSize int) []byte {
 	return append(data, padText...)
 }
 
-// pkcs7UnPadding PKCS7 unpadding
+// pkcs7UnPadding PKCS7 unpadding, every padding byte must hold the padding length.
 func pkcs7UnPadding(data []byte) ([]byte, error) {
 	length := len(data)
 	if length == 0 {
 		return nil, errs.New("data is nil")
 	}
 	unPadding := int(data[length-1])
+	if unPadding == 0 || unPadding > aes.BlockSize || unPadding > length {
+		return nil, errs.New("invalid padding")
+	}
+	for _, b := range data[length-unPadding:] {
+		if int(b) != unPadding {
+			return nil, errs.New("invalid padding")
+		}
+	}
 	return data[:(length - unPadding)], nil
 }
+
+// AesGCMEncrypt encrypts the data with the key using AES-GCM. A random nonce is generated for
+// every call and prefixed to the returned ciphertext.
+func AesGCMEncrypt(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errs.WrapMsg(err, "generate nonce failed")
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// AesGCMDecrypt decrypts the data encrypted by AesGCMEncrypt. A wrong key or tampered data is
+// an error.
+func AesGCMDecrypt(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errs.New("data is too short", "length", len(data))
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "gcm open failed")
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "NewCipher failed", "length", len(key))
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "NewGCM failed")
+	}
+	return gcm, nil
+}
@@ -1,6 +1,7 @@
 package encrypt
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -41,3 +42,66 @@ func TestAesEncryptionDecryption(t *testing.T) {
 		t.Errorf("AesDecrypt = %v, want %v", string(decrypted), originalText)
 	}
 }
+
+func TestAesDecryptInvalidData(t *testing.T) {
+	key := []byte("1234567890123456")
+	if _, err := AesDecrypt([]byte("short"), key); err == nil {
+		t.Errorf("AesDecrypt accepted data shorter than a block")
+	}
+	encrypted, err := AesEncrypt([]byte("Hello, World!"), key)
+	if err != nil {
+		t.Fatalf("AesEncrypt error: %v", err)
+	}
+	// A wrong key yields garbage padding, it must be an error rather than a panic.
+	for _, wrong := range []string{"6543210987654321", "abcdefghijklmnop", "0000000000000000"} {
+		if decrypted, err := AesDecrypt(encrypted, []byte(wrong)); err == nil && string(decrypted) == "Hello, World!" {
+			t.Errorf("AesDecrypt with key %s decrypted the data", wrong)
+		}
+	}
+}
+
+func TestPkcs7UnPadding(t *testing.T) {
+	for _, data := range [][]byte{
+		append(bytes.Repeat([]byte{'a'}, 15), 0),
+		append(bytes.Repeat([]byte{'a'}, 15), 2),
+		append(bytes.Repeat([]byte{'a'}, 16), bytes.Repeat([]byte{17}, 16)...),
+		append(bytes.Repeat([]byte{'a'}, 13), 1, 3, 3),
+	} {
+		if _, err := pkcs7UnPadding(data); err == nil {
+			t.Errorf("pkcs7UnPadding accepted %v", data)
+		}
+	}
+	plain, err := pkcs7UnPadding(append([]byte("abcdefghijklm"), 3, 3, 3))
+	if err != nil || string(plain) != "abcdefghijklm" {
+		t.Errorf("pkcs7UnPadding = %q, %v", plain, err)
+	}
+}
+
+func TestAesGCM(t *testing.T) {
+	key := []byte("1234567890123456")
+	first, err := AesGCMEncrypt([]byte("Hello, World!"), key)
+	if err != nil {
+		t.Fatalf("AesGCMEncrypt error: %v", err)
+	}
+	second, err := AesGCMEncrypt([]byte("Hello, World!"), key)
+	if err != nil {
+		t.Fatalf("AesGCMEncrypt error: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Errorf("AesGCMEncrypt produced equal ciphertexts for equal plaintexts")
+	}
+	decrypted, err := AesGCMDecrypt(first, key)
+	if err != nil || string(decrypted) != "Hello, World!" {
+		t.Errorf("AesGCMDecrypt = %q, %v", decrypted, err)
+	}
+	if _, err := AesGCMDecrypt(first, []byte("6543210987654321")); err == nil {
+		t.Errorf("AesGCMDecrypt accepted a wrong key")
+	}
+	first[len(first)-1] ^= 1
+	if _, err := AesGCMDecrypt(first, key); err == nil {
+		t.Errorf("AesGCMDecrypt accepted tampered data")
+	}
+	if _, err := AesGCMDecrypt([]byte("short"), key); err == nil {
+		t.Errorf("AesGCMDecrypt accepted data shorter than a nonce")
+	}
+}